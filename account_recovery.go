@@ -0,0 +1,75 @@
+package cnlib
+
+// recoveryPurposes are the standard purposes scanned when recovering a seed from an unknown wallet. BIP86 is left out, as
+// its keys are limited to message signing.
+var recoveryPurposes = []int{44, 49, 84}
+
+/// Type Definitions
+
+// RecoveredAccount is an account with on-chain activity found by RecoverAccounts.
+type RecoveredAccount struct {
+	BaseCoin         *BaseCoin
+	UsedAddressCount int
+	NextReceiveIndex int
+	NextChangeIndex  int
+}
+
+// AccountRecoveryResult is a gomobile-friendly list of RecoveredAccounts, in purpose then account order.
+type AccountRecoveryResult struct {
+	accounts []*RecoveredAccount
+}
+
+/// Receiver methods
+
+// Count returns the number of accounts with activity.
+func (r *AccountRecoveryResult) Count() int {
+	return len(r.accounts)
+}
+
+// AccountAtIndex returns the RecoveredAccount at index i, or nil if out of range.
+func (r *AccountRecoveryResult) AccountAtIndex(i int) *RecoveredAccount {
+	if i < 0 || i >= len(r.accounts) {
+		return nil
+	}
+	return r.accounts[i]
+}
+
+/// Package functions
+
+// RecoverAccounts scans purposes 44, 49 and 84 for the given coin (0 mainnet, 1 testnet). For each purpose, accounts
+// are scanned from 0 with DiscoverAddresses until one has no used addresses, following BIP44 account discovery.
+func RecoverAccounts(wordString string, passphrase string, oracle AddressUsageOracle, coin int, gapLimit int) (*AccountRecoveryResult, error) {
+	if coin != mainnet && coin != testnet {
+		return nil, ErrInvalidCoinValue
+	}
+
+	wallet, err := NewHDWalletFromMnemonic(wordString, passphrase, NewBaseCoin(recoveryPurposes[0], coin, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AccountRecoveryResult{}
+	for _, purpose := range recoveryPurposes {
+		for account := 0; ; account++ {
+			basecoin := NewBaseCoin(purpose, coin, account)
+			wallet.UpdateCoin(basecoin)
+
+			discovered, err := wallet.DiscoverAddresses(oracle, gapLimit, 0)
+			if err != nil {
+				return nil, err
+			}
+			if discovered.UsedAddresses.Count() == 0 {
+				break
+			}
+
+			result.accounts = append(result.accounts, &RecoveredAccount{
+				BaseCoin:         basecoin,
+				UsedAddressCount: discovered.UsedAddresses.Count(),
+				NextReceiveIndex: discovered.NextReceiveIndex,
+				NextChangeIndex:  discovered.NextChangeIndex,
+			})
+		}
+	}
+
+	return result, nil
+}
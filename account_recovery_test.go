@@ -0,0 +1,76 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func markUsed(t *testing.T, oracle *MemoryAddressUsageOracle, wallet *HDWallet, basecoin *BaseCoin, change int, index int) {
+	wallet.UpdateCoin(basecoin)
+	ma, err := wallet.MessageSigningAddressForIndex(change, index)
+	assert.Nil(t, err)
+	oracle.AddUsedAddress(ma.Address)
+}
+
+func TestRecoverAccounts(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	oracle := NewMemoryAddressUsageOracle()
+
+	markUsed(t, oracle, wallet, NewBaseCoin(44, 0, 0), 0, 2)
+	markUsed(t, oracle, wallet, NewBaseCoin(84, 0, 0), 0, 0)
+	markUsed(t, oracle, wallet, NewBaseCoin(84, 0, 0), 1, 4)
+	markUsed(t, oracle, wallet, NewBaseCoin(84, 0, 1), 0, 7)
+	// BIP86 keys are limited to message signing, so their history is not recovered
+	markUsed(t, oracle, wallet, NewBaseCoin(86, 0, 0), 0, 1)
+	// account 3 follows an empty account 2, so it is not scanned
+	markUsed(t, oracle, wallet, NewBaseCoin(84, 0, 3), 0, 0)
+
+	result, err := RecoverAccounts(w, "", oracle, 0, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.Count())
+
+	expected := []*RecoveredAccount{
+		{BaseCoin: NewBaseCoin(44, 0, 0), UsedAddressCount: 1, NextReceiveIndex: 3, NextChangeIndex: 0},
+		{BaseCoin: NewBaseCoin(84, 0, 0), UsedAddressCount: 2, NextReceiveIndex: 1, NextChangeIndex: 5},
+		{BaseCoin: NewBaseCoin(84, 0, 1), UsedAddressCount: 1, NextReceiveIndex: 8, NextChangeIndex: 0},
+	}
+	for i, account := range expected {
+		assert.Equal(t, account, result.AccountAtIndex(i))
+	}
+	assert.Nil(t, result.AccountAtIndex(3))
+}
+
+func TestRecoverAccounts_Passphrase(t *testing.T) {
+	wallet := NewHDWalletFromWordsWithPassphrase(w, "TREZOR", BaseCoinBip49TestNet)
+	oracle := NewMemoryAddressUsageOracle()
+	markUsed(t, oracle, wallet, BaseCoinBip49TestNet, 0, 0)
+
+	result, err := RecoverAccounts(w, "TREZOR", oracle, 1, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Count())
+	assert.Equal(t, BaseCoinBip49TestNet, result.AccountAtIndex(0).BaseCoin)
+
+	// without the passphrase it is a different wallet
+	result, err = RecoverAccounts(w, "", oracle, 1, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.Count())
+}
+
+func TestRecoverAccounts_Errors(t *testing.T) {
+	_, err := RecoverAccounts(w, "", NewMemoryAddressUsageOracle(), 2, 20)
+	assert.Equal(t, ErrInvalidCoinValue, err)
+
+	_, err = RecoverAccounts(w, "", NewMemoryAddressUsageOracle(), 0, 0)
+	assert.Equal(t, ErrInvalidGapLimit, err)
+
+	_, err = RecoverAccounts("abandon abandon abandon", "", NewMemoryAddressUsageOracle(), 0, 20)
+	assert.Equal(t, ErrInvalidMnemonic, err)
+}
+
+func TestNewHDWalletFromWordsWithPassphrase(t *testing.T) {
+	// BIP39 test vector: "abandon ... about" with passphrase TREZOR
+	wallet := NewHDWalletFromWordsWithPassphrase(w, "TREZOR", BaseCoinBip84MainNet)
+	assert.Equal(t, "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF", wallet.masterPrivateKey.String())
+	assert.Equal(t, w, wallet.WalletWords)
+}
@@ -0,0 +1,200 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// ErrInvalidGapLimit describes an error in which a discovery gap limit is not positive.
+var ErrInvalidGapLimit = errors.New("gap limit must be greater than zero")
+
+/// Type Definitions
+
+// AddressUsageOracle is implemented by the caller to report which addresses have been used on chain, e.g. by querying a
+// block explorer or an electrum server. CheckUsage should call MarkUsed for every used entry in the query.
+type AddressUsageOracle interface {
+	CheckUsage(query *AddressUsageQuery) error
+}
+
+// AddressUsageQuery is a batch of addresses, with their scriptPubKeys, passed to an AddressUsageOracle.
+type AddressUsageQuery struct {
+	addresses     []*MetaAddress
+	scriptPubKeys [][]byte
+	used          []bool
+}
+
+// AddressDiscoveryResult holds the used addresses found by discovery, and the first unused index past the last used address on each chain.
+type AddressDiscoveryResult struct {
+	UsedAddresses    *MetaAddressList
+	NextReceiveIndex int
+	NextChangeIndex  int
+}
+
+// MemoryAddressUsageOracle is an in-memory AddressUsageOracle, for tests and for callers who already hold their history.
+type MemoryAddressUsageOracle struct {
+	addresses     map[string]bool
+	scriptPubKeys map[string]bool
+}
+
+/// Constructors
+
+// NewMemoryAddressUsageOracle returns a pointer to an empty MemoryAddressUsageOracle.
+func NewMemoryAddressUsageOracle() *MemoryAddressUsageOracle {
+	return &MemoryAddressUsageOracle{addresses: map[string]bool{}, scriptPubKeys: map[string]bool{}}
+}
+
+func newAddressUsageQuery(addresses []*MetaAddress) (*AddressUsageQuery, error) {
+	scripts := make([][]byte, len(addresses))
+	for i, ma := range addresses {
+		script, err := scriptPubKeyForAddress(ma.Address)
+		if err != nil {
+			return nil, err
+		}
+		scripts[i] = script
+	}
+	return &AddressUsageQuery{addresses: addresses, scriptPubKeys: scripts, used: make([]bool, len(addresses))}, nil
+}
+
+/// Receiver methods
+
+// Count returns the number of addresses in the query.
+func (q *AddressUsageQuery) Count() int {
+	return len(q.addresses)
+}
+
+// AddressAtIndex returns the address at index i, or an empty string if out of range.
+func (q *AddressUsageQuery) AddressAtIndex(i int) string {
+	if i < 0 || i >= len(q.addresses) {
+		return ""
+	}
+	return q.addresses[i].Address
+}
+
+// ScriptPubKeyHexAtIndex returns the hex-encoded scriptPubKey at index i, or an empty string if out of range.
+func (q *AddressUsageQuery) ScriptPubKeyHexAtIndex(i int) string {
+	if i < 0 || i >= len(q.scriptPubKeys) {
+		return ""
+	}
+	return hex.EncodeToString(q.scriptPubKeys[i])
+}
+
+// MarkUsed records that the address at index i has been used. Out of range indexes are ignored.
+func (q *AddressUsageQuery) MarkUsed(i int) {
+	if i < 0 || i >= len(q.used) {
+		return
+	}
+	q.used[i] = true
+}
+
+// IsUsed returns whether the address at index i has been marked used.
+func (q *AddressUsageQuery) IsUsed(i int) bool {
+	if i < 0 || i >= len(q.used) {
+		return false
+	}
+	return q.used[i]
+}
+
+// AddUsedAddress marks an address as used.
+func (o *MemoryAddressUsageOracle) AddUsedAddress(address string) {
+	o.addresses[address] = true
+}
+
+// AddUsedScriptPubKeyHex marks a hex-encoded scriptPubKey as used.
+func (o *MemoryAddressUsageOracle) AddUsedScriptPubKeyHex(script string) error {
+	decoded, err := hex.DecodeString(script)
+	if err != nil {
+		return err
+	}
+	o.scriptPubKeys[hex.EncodeToString(decoded)] = true
+	return nil
+}
+
+// CheckUsage marks every address in the query whose address or scriptPubKey has been added as used.
+func (o *MemoryAddressUsageOracle) CheckUsage(query *AddressUsageQuery) error {
+	for i := 0; i < query.Count(); i++ {
+		if o.addresses[query.AddressAtIndex(i)] || o.scriptPubKeys[query.ScriptPubKeyHexAtIndex(i)] {
+			query.MarkUsed(i)
+		}
+	}
+	return nil
+}
+
+// DiscoverAddresses walks the receive and change chains of the current BaseCoin, asking the oracle about batchSize
+// addresses at a time, until gapLimit consecutive unused addresses follow the last used one. A batchSize of 0 uses gapLimit.
+// BIP86 wallets return ErrTaprootSpendingUnsupported.
+func (wallet *HDWallet) DiscoverAddresses(oracle AddressUsageOracle, gapLimit int, batchSize int) (*AddressDiscoveryResult, error) {
+	if gapLimit <= 0 {
+		return nil, ErrInvalidGapLimit
+	}
+	if batchSize <= 0 {
+		batchSize = gapLimit
+	}
+
+	basecoin := wallet.coin()
+	if basecoin.Purpose == bip86purpose {
+		return nil, ErrTaprootSpendingUnsupported
+	}
+	receiveUsed, nextReceive, err := wallet.discoverChain(oracle, basecoin, 0, gapLimit, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	changeUsed, nextChange, err := wallet.discoverChain(oracle, basecoin, 1, gapLimit, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	used := append(receiveUsed, changeUsed...)
+	return &AddressDiscoveryResult{
+		UsedAddresses:    &MetaAddressList{addresses: used},
+		NextReceiveIndex: nextReceive,
+		NextChangeIndex:  nextChange,
+	}, nil
+}
+
+/// Unexported functions
+
+// discoverChain returns the used addresses on one chain, and the index after the last used address.
+func (wallet *HDWallet) discoverChain(oracle AddressUsageOracle, basecoin *BaseCoin, change int, gapLimit int, batchSize int) ([]*MetaAddress, int, error) {
+	var used []*MetaAddress
+	next := 0
+
+	for start := 0; start-next < gapLimit; start += batchSize {
+		list, err := wallet.addressesForRange(basecoin, change, start, batchSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		batch := list.addresses
+
+		query, err := newAddressUsageQuery(batch)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := oracle.CheckUsage(query); err != nil {
+			return nil, 0, err
+		}
+
+		for i, ma := range batch {
+			if query.IsUsed(i) {
+				used = append(used, ma)
+				next = start + i + 1
+			}
+		}
+	}
+
+	return used, next, nil
+}
+
+// scriptPubKeyForAddress returns the output script paying to an address on any supported network.
+func scriptPubKeyForAddress(address string) ([]byte, error) {
+	if outputKey, _, err := decodeTaprootAddress(address); err == nil {
+		return taprootScript(outputKey), nil
+	}
+
+	addr, err := decodeAddressForAnyNetwork(address)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(addr)
+}
@@ -0,0 +1,115 @@
+package cnlib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingOracle struct {
+	*MemoryAddressUsageOracle
+	batches []int
+}
+
+func (o *countingOracle) CheckUsage(query *AddressUsageQuery) error {
+	o.batches = append(o.batches, query.Count())
+	return o.MemoryAddressUsageOracle.CheckUsage(query)
+}
+
+type failingOracle struct{}
+
+func (o failingOracle) CheckUsage(query *AddressUsageQuery) error {
+	return errors.New("server unavailable")
+}
+
+func TestDiscoverAddresses_EmptyWallet(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	oracle := &countingOracle{MemoryAddressUsageOracle: NewMemoryAddressUsageOracle()}
+
+	result, err := wallet.DiscoverAddresses(oracle, 20, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.UsedAddresses.Count())
+	assert.Equal(t, 0, result.NextReceiveIndex)
+	assert.Equal(t, 0, result.NextChangeIndex)
+	assert.Equal(t, []int{20, 20}, oracle.batches)
+}
+
+func TestDiscoverAddresses_RespectsGapLimit(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	oracle := NewMemoryAddressUsageOracle()
+
+	for _, i := range []int{0, 3, 12} {
+		ma, err := wallet.ReceiveAddressForIndex(i)
+		assert.Nil(t, err)
+		oracle.AddUsedAddress(ma.Address)
+	}
+	// beyond the gap of 10 after index 12, so never found
+	far, err := wallet.ReceiveAddressForIndex(30)
+	assert.Nil(t, err)
+	oracle.AddUsedAddress(far.Address)
+
+	change, err := wallet.ChangeAddressForIndex(1)
+	assert.Nil(t, err)
+	query, err := newAddressUsageQuery([]*MetaAddress{change})
+	assert.Nil(t, err)
+	assert.Nil(t, oracle.AddUsedScriptPubKeyHex(query.ScriptPubKeyHexAtIndex(0)))
+
+	result, err := wallet.DiscoverAddresses(oracle, 10, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 13, result.NextReceiveIndex)
+	assert.Equal(t, 2, result.NextChangeIndex)
+	assert.Equal(t, 4, result.UsedAddresses.Count())
+	assert.Equal(t, change.Address, result.UsedAddresses.MetaAddressAtIndex(3).Address)
+	assert.Nil(t, result.UsedAddresses.MetaAddressAtIndex(4))
+
+	result, err = wallet.DiscoverAddresses(oracle, 20, 7)
+	assert.Nil(t, err)
+	assert.Equal(t, 31, result.NextReceiveIndex)
+}
+
+func TestDiscoverAddresses_WatchOnly(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+	xpub, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	watchOnly, err := NewHDWalletFromAccountExtendedPublicKey(xpub)
+	assert.Nil(t, err)
+
+	oracle := NewMemoryAddressUsageOracle()
+	ma, err := wallet.ReceiveAddressForIndex(5)
+	assert.Nil(t, err)
+	oracle.AddUsedAddress(ma.Address)
+
+	result, err := watchOnly.DiscoverAddresses(oracle, 20, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, result.NextReceiveIndex)
+	assert.Equal(t, ma.Address, result.UsedAddresses.MetaAddressAtIndex(0).Address)
+}
+
+func TestDiscoverAddresses_Taproot(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, NewBaseCoin(86, 0, 0))
+	ma, err := wallet.MessageSigningAddressForIndex(0, 0)
+	assert.Nil(t, err)
+
+	query, err := newAddressUsageQuery([]*MetaAddress{ma})
+	assert.Nil(t, err)
+	assert.Equal(t, "5120", query.ScriptPubKeyHexAtIndex(0)[:4])
+	assert.Equal(t, "", query.ScriptPubKeyHexAtIndex(1))
+	assert.Equal(t, "", query.AddressAtIndex(-1))
+
+	result, err := wallet.DiscoverAddresses(NewMemoryAddressUsageOracle(), 5, 0)
+	assert.Equal(t, ErrTaprootSpendingUnsupported, err)
+	assert.Nil(t, result)
+}
+
+func TestDiscoverAddresses_Errors(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	_, err := wallet.DiscoverAddresses(NewMemoryAddressUsageOracle(), 0, 0)
+	assert.Equal(t, ErrInvalidGapLimit, err)
+
+	_, err = wallet.DiscoverAddresses(failingOracle{}, 20, 0)
+	assert.EqualError(t, err, "server unavailable")
+
+	assert.NotNil(t, NewMemoryAddressUsageOracle().AddUsedScriptPubKeyHex("zz"))
+}
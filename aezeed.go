@@ -0,0 +1,94 @@
+package cnlib
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+)
+
+var (
+	// ErrInvalidAezeedWordCount describes an error in which an aezeed mnemonic does not have exactly 24 words.
+	ErrInvalidAezeedWordCount = errors.New("aezeed mnemonic must have 24 words")
+)
+
+/// Type Definition
+
+// Aezeed is a decoded lnd cipher seed. Birthday is the number of days after the bitcoin genesis block on which the
+// seed was created.
+type Aezeed struct {
+	Mnemonic        string
+	InternalVersion int
+	Birthday        int
+	BirthdayUnix    int64
+	entropy         [aezeed.EntropySize]byte
+}
+
+/// Constructors
+
+// NewAezeed creates an aezeed with fresh entropy and today's birthday, enciphered with an optional passphrase.
+func NewAezeed(passphrase string) (*Aezeed, error) {
+	var entropy [aezeed.EntropySize]byte
+	if _, err := io.ReadFull(entropyReader, entropy[:]); err != nil {
+		return nil, err
+	}
+	cipherSeed, err := aezeed.New(aezeed.CipherSeedVersion, &entropy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err := cipherSeed.ToMnemonic([]byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	return newAezeed(cipherSeed, mnemonic), nil
+}
+
+// DecodeAezeed deciphers an aezeed mnemonic with its passphrase. An empty passphrase uses lnd's default. Returns
+// aezeed.ErrInvalidPass if the passphrase is wrong.
+func DecodeAezeed(wordString string, passphrase string) (*Aezeed, error) {
+	words := strings.Fields(strings.ToLower(wordString))
+	if len(words) != aezeed.NummnemonicWords {
+		return nil, ErrInvalidAezeedWordCount
+	}
+	var mnemonic aezeed.Mnemonic
+	copy(mnemonic[:], words)
+
+	cipherSeed, err := mnemonic.ToCipherSeed([]byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	return newAezeed(cipherSeed, mnemonic), nil
+}
+
+// NewHDWalletFromAezeed returns a pointer to an HDWallet whose BIP32 seed is an aezeed's entropy, matching the
+// on-chain wallet of an lnd node.
+func NewHDWalletFromAezeed(wordString string, passphrase string, basecoin *BaseCoin) (*HDWallet, error) {
+	if basecoin == nil {
+		return nil, errors.New("no basecoin provided")
+	}
+	seed, err := DecodeAezeed(wordString, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := hdkeychain.NewMaster(seed.entropy[:], basecoin.defaultNetParams())
+	if err != nil {
+		return nil, err
+	}
+	primePublicKey(masterKey)
+	return newHDWalletFromMasterKey(masterKey, "", basecoin)
+}
+
+/// Unexported functions
+
+func newAezeed(cipherSeed *aezeed.CipherSeed, mnemonic aezeed.Mnemonic) *Aezeed {
+	return &Aezeed{
+		Mnemonic:        strings.Join(mnemonic[:], " "),
+		InternalVersion: int(cipherSeed.InternalVersion),
+		Birthday:        int(cipherSeed.Birthday),
+		BirthdayUnix:    cipherSeed.BirthdayTime().Unix(),
+		entropy:         cipherSeed.Entropy,
+	}
+}
@@ -0,0 +1,119 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/lightningnetwork/lnd/aezeed"
+	"github.com/stretchr/testify/assert"
+)
+
+// vectors made with lnd's aezeed package at its default scrypt cost, from lnd's version0TestVectors entropy: one
+// created at the genesis date with no passphrase, and one on 2018-03-23 with a custom passphrase
+const (
+	aezeedTestEntropy      = "81b637d86359e6960de795e41e0b4cfd"
+	aezeedDefaultPassSeed  = "about buffalo rhythm unfair beyond stem smart whisper deal sell habit identify wood brick solve eight town behave voice help empower dignity wrong chase"
+	aezeedDefaultPassSalt  = "8fd5b58490"
+	aezeedCustomPassSeed   = "ability result answer stereo south test inch skin spice about type flat embody salt suffer wheat caution potato license soda forest roof broom patch"
+	aezeedCustomPassSalt   = "1a04e715b3"
+	aezeedCustomPassphrase = "!very_safe_55345_password*"
+)
+
+// aezeedSalt returns the salt of an enciphered aezeed, which is stored in the clear ahead of its checksum.
+func aezeedSalt(wordString string) string {
+	data := new(big.Int)
+	for _, word := range strings.Fields(wordString) {
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(bip39WordIndexes()[BIP39LanguageEnglish][word])))
+	}
+	enciphered := make([]byte, aezeed.EncipheredCipherSeedSize)
+	dataBytes := data.Bytes()
+	copy(enciphered[len(enciphered)-len(dataBytes):], dataBytes)
+	return hex.EncodeToString(enciphered[24:29])
+}
+
+func TestDecodeAezeed_TestVectors(t *testing.T) {
+	for _, v := range []struct {
+		mnemonic   string
+		passphrase string
+		salt       string
+		birthday   int
+	}{
+		{aezeedDefaultPassSeed, "", aezeedDefaultPassSalt, 0},
+		{aezeedCustomPassSeed, aezeedCustomPassphrase, aezeedCustomPassSalt, 3365},
+	} {
+		assert.Equal(t, v.salt, aezeedSalt(v.mnemonic))
+
+		seed, err := DecodeAezeed(v.mnemonic, v.passphrase)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, seed.InternalVersion)
+		assert.Equal(t, v.birthday, seed.Birthday)
+		assert.Equal(t, aezeed.BitcoinGenesisDate.Unix()+int64(v.birthday)*86400, seed.BirthdayUnix)
+		assert.Equal(t, aezeedTestEntropy, hex.EncodeToString(seed.entropy[:]))
+		assert.Equal(t, v.mnemonic, seed.Mnemonic)
+	}
+}
+
+func TestDecodeAezeed_WrongPassphrase(t *testing.T) {
+	seed, err := DecodeAezeed(aezeedCustomPassSeed, "wrong")
+	assert.Nil(t, seed)
+	assert.Equal(t, aezeed.ErrInvalidPass, err)
+
+	_, err = DecodeAezeed(aezeedCustomPassSeed, "")
+	assert.Equal(t, aezeed.ErrInvalidPass, err)
+}
+
+func TestDecodeAezeed_InvalidWords(t *testing.T) {
+	_, err := DecodeAezeed(w, "")
+	assert.Equal(t, ErrInvalidAezeedWordCount, err)
+
+	words := strings.Fields(aezeedDefaultPassSeed)
+	words[23] = "ability"
+	_, err = DecodeAezeed(strings.Join(words, " "), "")
+	assert.Equal(t, aezeed.ErrIncorrectMnemonic, err)
+}
+
+func TestNewAezeed_RoundTrip(t *testing.T) {
+	entropy, _ := hex.DecodeString(aezeedTestEntropy)
+	defer withEntropyReader(entropy)()
+
+	created, err := NewAezeed("passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, 24, len(strings.Fields(created.Mnemonic)))
+	assert.Equal(t, int(aezeed.CipherSeedVersion), created.InternalVersion)
+	assert.True(t, created.Birthday > 3365)
+
+	decoded, err := DecodeAezeed(created.Mnemonic, "passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, created.Birthday, decoded.Birthday)
+	assert.Equal(t, aezeedTestEntropy, hex.EncodeToString(decoded.entropy[:]))
+}
+
+func TestNewHDWalletFromAezeed(t *testing.T) {
+	basecoin := NewBaseCoin(84, 0, 0)
+	wallet, err := NewHDWalletFromAezeed(aezeedCustomPassSeed, aezeedCustomPassphrase, basecoin)
+	assert.Nil(t, err)
+	assert.Equal(t, "", wallet.WalletWords)
+
+	entropy, _ := hex.DecodeString(aezeedTestEntropy)
+	masterKey, _ := hdkeychain.NewMaster(entropy, &chaincfg.MainNetParams)
+	expected, err := NewHDWalletFromMasterExtendedPrivateKey(masterKey.String(), basecoin)
+	assert.Nil(t, err)
+
+	expectedPub, _ := expected.AccountExtendedMasterPublicKey()
+	pub, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedPub, pub)
+
+	expectedAddr, _ := expected.ReceiveAddressForIndex(0)
+	addr, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedAddr.Address, addr.Address)
+
+	_, err = NewHDWalletFromAezeed(aezeedCustomPassSeed, aezeedCustomPassphrase, nil)
+	assert.NotNil(t, err)
+}
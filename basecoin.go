@@ -1,12 +1,9 @@
 package cnlib
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
 
 	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/btcsuite/btcutil/base58"
 )
 
 var (
@@ -50,43 +47,27 @@ func NewBaseCoin(purpose int, coin int, account int) *BaseCoin {
 	return &BaseCoin{Purpose: purpose, Coin: coin, Account: account}
 }
 
-// NewBaseCoinFromAccountPubKey returns a new BaseCoin pointer based on prefix, or error if unrecognized.
+// NewBaseCoinFromAccountPubKey returns a new BaseCoin pointer based on prefix, or error if unrecognized. The key must be
+// a valid account-level (depth 3, hardened) extended public key.
 func NewBaseCoinFromAccountPubKey(key string) (*BaseCoin, error) {
-	// prefix := key[:4]
-	var bc *BaseCoin
-
-	dec, _, err := base58.CheckDecode(key)
+	parsed, err := parseSLIP132Key(key)
 	if err != nil {
 		return nil, err
 	}
 
-	decoded := []byte{0x04}
-	decoded = append(decoded, dec...)
-
-	acctBytes := decoded[9:13]                                   // bytes 9 through 12 are the 4 bytes of the account "child number", but first byte 0x04 is dropped in CheckDecode
-	acctIndex := binary.BigEndian.Uint32(acctBytes) & 0x0FFFFFFF // if hardened, remove hardened offset
-	acct := int(acctIndex)
-
-	prefix := decoded[:4]
-	if bytes.Equal(prefix, pubkeyIDs[xpub]) {
-		bc = &BaseCoin{Purpose: bip44purpose, Coin: mainnet, Account: acct}
-	} else if bytes.Equal(prefix, pubkeyIDs[ypub]) {
-		bc = &BaseCoin{Purpose: bip49purpose, Coin: mainnet, Account: acct}
-	} else if bytes.Equal(prefix, pubkeyIDs[zpub]) {
-		bc = &BaseCoin{Purpose: bip84purpose, Coin: mainnet, Account: acct}
-	} else if bytes.Equal(prefix, pubkeyIDs[tpub]) {
-		bc = &BaseCoin{Purpose: bip44purpose, Coin: testnet, Account: acct}
-	} else if bytes.Equal(prefix, pubkeyIDs[upub]) {
-		bc = &BaseCoin{Purpose: bip49purpose, Coin: testnet, Account: acct}
-	} else if bytes.Equal(prefix, pubkeyIDs[vpub]) {
-		bc = &BaseCoin{Purpose: bip84purpose, Coin: testnet, Account: acct}
+	if slip132Prefixes[parsed.prefix].private {
+		return nil, errors.New("unrecognized account key prefix")
 	}
+	return baseCoinFromAccountKey(parsed)
+}
 
-	if bc != nil {
-		return bc, nil
+// clone returns a copy of the BaseCoin, or nil for a nil receiver.
+func (bc *BaseCoin) clone() *BaseCoin {
+	if bc == nil {
+		return nil
 	}
-
-	return nil, errors.New("unrecognized account key prefix")
+	c := *bc
+	return &c
 }
 
 // UpdatePurpose updates the purpose value on the BaseCoin receiver.
@@ -110,7 +91,7 @@ func (bc *BaseCoin) GetBech32HRP() (string, error) {
 		return "", errors.New("no basecoin provided")
 	}
 
-	if bc.Purpose != 84 {
+	if bc.Purpose != bip84purpose && bc.Purpose != bip86purpose {
 		return "", errors.New("basecoin purpose is not a segwit purpose")
 	}
 	if bc.Coin == 0 {
@@ -124,7 +105,8 @@ func (bc *BaseCoin) isTestNet() bool {
 }
 
 func (bc *BaseCoin) defaultExtendedPubkeyType() (string, error) {
-	if bc.Purpose == bip44purpose {
+	// BIP86 defines no SLIP-132 prefix of its own, and uses standard xpub/tpub serialization.
+	if bc.Purpose == bip44purpose || bc.Purpose == bip86purpose {
 		if bc.Coin == mainnet {
 			return xpub, nil
 		}
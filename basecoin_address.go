@@ -13,6 +13,7 @@ const (
 	bip44purpose = 44
 	bip49purpose = 49
 	bip84purpose = 84
+	bip86purpose = 86
 )
 
 // constants for size in bytes of pieces of a transaction
@@ -0,0 +1,210 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	bip38KeySize = 39
+
+	bip38FlagCompressed  = 0x20
+	bip38FlagLotSequence = 0x04
+)
+
+var (
+	bip38NonECPrefix = []byte{0x01, 0x42}
+	bip38ECPrefix    = []byte{0x01, 0x43}
+)
+
+var (
+	// ErrInvalidBIP38Key describes an error in which an encrypted key is not a well formed BIP38 key.
+	ErrInvalidBIP38Key = errors.New("invalid BIP38 encrypted key")
+
+	// ErrBIP38InvalidPassphrase describes an error in which a BIP38 key did not decrypt to the address it commits to,
+	// which almost always means the passphrase is wrong.
+	ErrBIP38InvalidPassphrase = errors.New("invalid BIP38 passphrase")
+)
+
+/// Package functions
+
+// IsBIP38EncryptedKey returns whether a scanned string is a BIP38 encrypted private key, i.e. a "6P..." key.
+func IsBIP38EncryptedKey(encodedKey string) bool {
+	_, err := decodeBIP38Key(encodedKey)
+	return err == nil
+}
+
+/// Receiver methods
+
+// ImportBIP38PrivateKey decrypts a BIP38 encrypted private key, EC-multiplied or not, and returns the same
+// ImportedPrivateKey as ImportPrivateKey would for the decrypted WIF.
+func (wallet *HDWallet) ImportBIP38PrivateKey(encryptedKey string, passphrase string) (*ImportedPrivateKey, error) {
+	wif, err := decryptBIP38(encryptedKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.importedPrivateKeyFromWIF(wif)
+}
+
+/// Unexported functions
+
+// decryptBIP38 returns the WIF for a BIP38 key. BIP38 only defines mainnet addresses, so the WIF is for mainnet.
+func decryptBIP38(encryptedKey string, passphrase string) (*btcutil.WIF, error) {
+	decoded, err := decodeBIP38Key(encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	flag := decoded[2]
+	addressHash := decoded[3:7]
+	compressed := flag&bip38FlagCompressed != 0
+	normalized := norm.NFC.Bytes([]byte(passphrase))
+
+	var privKeyBytes []byte
+	if bytes.Equal(decoded[:2], bip38NonECPrefix) {
+		privKeyBytes, err = decryptBIP38NonEC(decoded, normalized)
+	} else {
+		privKeyBytes, err = decryptBIP38EC(decoded, normalized)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(big.Int).SetBytes(privKeyBytes)
+	if d.Sign() == 0 || d.Cmp(btcec.S256().N) >= 0 {
+		return nil, ErrBIP38InvalidPassphrase
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyBytes)
+
+	wif, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, err := bip38AddressHash(wif.SerializePubKey())
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(checksum, addressHash) {
+		return nil, ErrBIP38InvalidPassphrase
+	}
+	return wif, nil
+}
+
+func decodeBIP38Key(encryptedKey string) ([]byte, error) {
+	payload, version, err := base58.CheckDecode(encryptedKey)
+	if err != nil {
+		return nil, ErrInvalidBIP38Key
+	}
+	decoded := append([]byte{version}, payload...)
+	if len(decoded) != bip38KeySize {
+		return nil, ErrInvalidBIP38Key
+	}
+	if !bytes.Equal(decoded[:2], bip38NonECPrefix) && !bytes.Equal(decoded[:2], bip38ECPrefix) {
+		return nil, ErrInvalidBIP38Key
+	}
+	return decoded, nil
+}
+
+// decryptBIP38NonEC decrypts a key encrypted directly with a scrypt key from the passphrase.
+func decryptBIP38NonEC(decoded []byte, passphrase []byte) ([]byte, error) {
+	addressHash := decoded[3:7]
+	derived, err := scrypt.Key(passphrase, addressHash, 16384, 8, 8, 64)
+	if err != nil {
+		return nil, err
+	}
+	derivedHalf1, derivedHalf2 := derived[:32], derived[32:]
+
+	block, err := aes.NewCipher(derivedHalf2)
+	if err != nil {
+		return nil, err
+	}
+
+	privKey := make([]byte, 32)
+	block.Decrypt(privKey[:16], decoded[7:23])
+	block.Decrypt(privKey[16:], decoded[23:39])
+	for i := range privKey {
+		privKey[i] ^= derivedHalf1[i]
+	}
+	return privKey, nil
+}
+
+// decryptBIP38EC recovers seedb from an EC-multiplied key and multiplies its factor with the passphrase factor.
+func decryptBIP38EC(decoded []byte, passphrase []byte) ([]byte, error) {
+	flag := decoded[2]
+	addressHash := decoded[3:7]
+	ownerEntropy := decoded[7:15]
+	encryptedPart1 := decoded[15:23]
+	encryptedPart2 := decoded[23:39]
+
+	ownerSalt := ownerEntropy
+	if flag&bip38FlagLotSequence != 0 {
+		ownerSalt = ownerEntropy[:4]
+	}
+
+	passFactor, err := scrypt.Key(passphrase, ownerSalt, 16384, 8, 8, 32)
+	if err != nil {
+		return nil, err
+	}
+	if flag&bip38FlagLotSequence != 0 {
+		passFactor = chainhash.DoubleHashB(append(passFactor, ownerEntropy...))
+	}
+
+	_, passPoint := btcec.PrivKeyFromBytes(btcec.S256(), passFactor)
+	salt := append(append([]byte{}, addressHash...), ownerEntropy...)
+	derived, err := scrypt.Key(passPoint.SerializeCompressed(), salt, 1024, 1, 1, 64)
+	if err != nil {
+		return nil, err
+	}
+	derivedHalf1, derivedHalf2 := derived[:32], derived[32:]
+
+	block, err := aes.NewCipher(derivedHalf2)
+	if err != nil {
+		return nil, err
+	}
+
+	// encryptedpart2 decrypts to encryptedpart1[8:16] || seedb[16:24]
+	decrypted2 := make([]byte, 16)
+	block.Decrypt(decrypted2, encryptedPart2)
+	for i := range decrypted2 {
+		decrypted2[i] ^= derivedHalf1[16+i]
+	}
+
+	// encryptedpart1 decrypts to seedb[0:16]
+	decrypted1 := make([]byte, 16)
+	block.Decrypt(decrypted1, append(append([]byte{}, encryptedPart1...), decrypted2[:8]...))
+	for i := range decrypted1 {
+		decrypted1[i] ^= derivedHalf1[i]
+	}
+
+	seedB := append(decrypted1, decrypted2[8:]...)
+	factorB := chainhash.DoubleHashB(seedB)
+
+	n := btcec.S256().N
+	d := new(big.Int).Mul(new(big.Int).SetBytes(passFactor), new(big.Int).SetBytes(factorB))
+	d.Mod(d, n)
+
+	privKey := make([]byte, 32)
+	dBytes := d.Bytes()
+	copy(privKey[32-len(dBytes):], dBytes)
+	return privKey, nil
+}
+
+// bip38AddressHash returns the first four bytes of the double SHA256 of the key's mainnet P2PKH address.
+func bip38AddressHash(serializedPubkey []byte) ([]byte, error) {
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(serializedPubkey), &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.DoubleHashB([]byte(address.EncodeAddress()))[:4], nil
+}
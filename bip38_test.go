@@ -0,0 +1,69 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// official BIP38 test vectors
+var bip38TestVectors = []struct {
+	encrypted  string
+	passphrase string
+	wif        string
+}{
+	// no compression, no EC multiply
+	{"6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg", "TestingOneTwoThree", "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR"},
+	{"6PRNFFkZc2NZ6dJqFfhRoFNMR9Lnyj7dYGrzdgXXVMXcxoKTePPX1dWByq", "Satoshi", "5HtasZ6ofTHP6HCwTqTkLDuLQisYPah7aUnSKfC7h4hMUVw2gi5"},
+	{"6PRW5o9FLp4gJDDVqJQKJFTpMvdsSGJxMYHtHaQBF3ooa8mwD69bapcDQn", "\u03d2\u0301\u0000\U00010400\U0001f4a9", "5Jajm8eQ22H3pGWLEVCXyvND8dQZhiQhoLJNKjYXk9roUFTMSZ4"},
+	// compression, no EC multiply
+	{"6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo", "TestingOneTwoThree", "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP"},
+	{"6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7", "Satoshi", "KwYgW8gcxj1JWJXhPSu4Fqwzfhp5Yfi42mdYmMa4XqK7NJxXUSK7"},
+	// EC multiply, no compression, no lot/sequence numbers
+	{"6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX", "TestingOneTwoThree", "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2"},
+	{"6PfLGnQs6VZnrNpmVKfjotbnQuaJK4KZoPFrAjx1JMJUa1Ft8gnf5WxfKd", "Satoshi", "5KJ51SgxWaAYR13zd9ReMhJpwrcX47xTJh2D3fGPG9CM8vkv5sH"},
+	// EC multiply, no compression, lot/sequence numbers
+	{"6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j", "MOLON LABE", "5JLdxTtcTHcfYcmJsNVy1v2PMDx432JPoYcBTVVRHpPaxUrdtf8"},
+	{"6PgGWtx25kUg8QWvwuJAgorN6k9FbE25rv5dMRwu5SKMnfpfVe5mar2ngH", "ΜΟΛΩΝ ΛΑΒΕ", "5KMKKuUmAkiNbA3DazMQiLfDq47qs8MAEThm4yL8R2PhV1ov33D"},
+}
+
+func TestImportBIP38PrivateKey_TestVectors(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	for _, vector := range bip38TestVectors {
+		imported, err := wallet.ImportBIP38PrivateKey(vector.encrypted, vector.passphrase)
+		assert.Nil(t, err, vector.encrypted)
+		if err != nil {
+			continue
+		}
+		assert.Equal(t, vector.wif, imported.PrivateKeyAsWIF)
+
+		expected, err := wallet.ImportPrivateKey(vector.wif)
+		assert.Nil(t, err)
+		assert.Equal(t, expected.PossibleAddresses, imported.PossibleAddresses)
+	}
+}
+
+func TestImportBIP38PrivateKey_WrongPassphrase(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	_, err := wallet.ImportBIP38PrivateKey(bip38TestVectors[0].encrypted, "Satoshi")
+	assert.Equal(t, ErrBIP38InvalidPassphrase, err)
+
+	_, err = wallet.ImportBIP38PrivateKey(bip38TestVectors[5].encrypted, "Satoshi")
+	assert.Equal(t, ErrBIP38InvalidPassphrase, err)
+}
+
+func TestImportBIP38PrivateKey_InvalidKey(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	_, err := wallet.ImportBIP38PrivateKey("5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR", "TestingOneTwoThree")
+	assert.Equal(t, ErrInvalidBIP38Key, err)
+}
+
+func TestIsBIP38EncryptedKey(t *testing.T) {
+	assert.True(t, IsBIP38EncryptedKey(bip38TestVectors[0].encrypted))
+	assert.True(t, IsBIP38EncryptedKey(bip38TestVectors[7].encrypted))
+	assert.False(t, IsBIP38EncryptedKey(bip38TestVectors[0].wif))
+	assert.False(t, IsBIP38EncryptedKey("6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGh"))
+}
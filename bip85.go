@@ -0,0 +1,149 @@
+package cnlib
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const (
+	bip85Purpose    = 83696968
+	bip85AppBIP39   = 39
+	bip85AppWIF     = 2
+	bip85AppXPRV    = 32
+	bip85AppHex     = 128169
+	bip85MinHexSize = 16
+	bip85MaxHexSize = 64
+)
+
+var bip85HMACKey = []byte("bip-entropy-from-k")
+
+// bip85LanguageCodes maps wordlist languages to their BIP85 language index.
+var bip85LanguageCodes = map[string]uint32{
+	BIP39LanguageEnglish:            0,
+	BIP39LanguageJapanese:           1,
+	BIP39LanguageKorean:             2,
+	BIP39LanguageSpanish:            3,
+	BIP39LanguageChineseSimplified:  4,
+	BIP39LanguageChineseTraditional: 5,
+	BIP39LanguageFrench:             6,
+	BIP39LanguageItalian:            7,
+}
+
+var (
+	// ErrInvalidBIP85Index describes an error in which a BIP85 index or length is negative or out of range.
+	ErrInvalidBIP85Index = errors.New("invalid BIP85 index")
+)
+
+/// Receiver methods
+
+// BIP85Mnemonic returns the child BIP39 mnemonic at m/83696968'/39'/language'/words'/index'.
+func (wallet *HDWallet) BIP85Mnemonic(wordCount int, language string, index int) (string, error) {
+	languageCode, ok := bip85LanguageCodes[language]
+	if !ok {
+		return "", ErrUnsupportedMnemonicLanguage
+	}
+	size, err := entropySizeForWordCount(wordCount)
+	if err != nil {
+		return "", err
+	}
+	if !validBIP85Index(index) {
+		return "", ErrInvalidBIP85Index
+	}
+
+	entropy, err := wallet.keyFactory().bip85Entropy(bip85AppBIP39, languageCode, uint32(wordCount), uint32(index))
+	if err != nil {
+		return "", err
+	}
+	return NewWordListFromEntropyForLanguage(entropy[:size], language)
+}
+
+// BIP85WIF returns the child private key at m/83696968'/2'/index' as a compressed WIF for the wallet's network.
+func (wallet *HDWallet) BIP85WIF(index int) (string, error) {
+	if !validBIP85Index(index) {
+		return "", ErrInvalidBIP85Index
+	}
+	entropy, err := wallet.keyFactory().bip85Entropy(bip85AppWIF, uint32(index))
+	if err != nil {
+		return "", err
+	}
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), entropy[:32])
+	wif, err := btcutil.NewWIF(privKey, wallet.coin().defaultNetParams(), true)
+	if err != nil {
+		return "", err
+	}
+	return wif.String(), nil
+}
+
+// BIP85ExtendedPrivateKey returns the child master xprv (tprv on testnet) at m/83696968'/32'/index'.
+func (wallet *HDWallet) BIP85ExtendedPrivateKey(index int) (string, error) {
+	if !validBIP85Index(index) {
+		return "", ErrInvalidBIP85Index
+	}
+	entropy, err := wallet.keyFactory().bip85Entropy(bip85AppXPRV, uint32(index))
+	if err != nil {
+		return "", err
+	}
+
+	chainCode, keyData := entropy[:32], entropy[32:]
+	d := new(big.Int).SetBytes(keyData)
+	if d.Sign() == 0 || d.Cmp(btcec.S256().N) >= 0 {
+		return "", ErrInvalidExtendedKey
+	}
+
+	params := wallet.coin().defaultNetParams()
+	key := hdkeychain.NewExtendedKey(params.HDPrivateKeyID[:], keyData, chainCode, []byte{0, 0, 0, 0}, 0, 0, true)
+	return key.String(), nil
+}
+
+// BIP85HexEntropy returns numBytes (16 to 64) of hex encoded child entropy at m/83696968'/128169'/numBytes'/index'.
+func (wallet *HDWallet) BIP85HexEntropy(numBytes int, index int) (string, error) {
+	if numBytes < bip85MinHexSize || numBytes > bip85MaxHexSize || !validBIP85Index(index) {
+		return "", ErrInvalidBIP85Index
+	}
+	entropy, err := wallet.keyFactory().bip85Entropy(bip85AppHex, uint32(numBytes), uint32(index))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(entropy[:numBytes]), nil
+}
+
+// bip85Entropy derives m/83696968'/app'/indexes'... from the master key, and returns HMAC-SHA512 of its private key.
+func (kf keyFactory) bip85Entropy(app uint32, indexes ...uint32) ([]byte, error) {
+	if kf.masterPrivateKey == nil {
+		return nil, kf.missingMasterKeyError()
+	}
+
+	path := append([]uint32{bip85Purpose, app}, indexes...)
+	key := kf.masterPrivateKey
+	for _, index := range path {
+		child, err := key.Child(hdkeychain.HardenedKeyStart + index)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+
+	privKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha512.New, bip85HMACKey)
+	mac.Write(privKey.Serialize())
+	return mac.Sum(nil), nil
+}
+
+/// Unexported functions
+
+// validBIP85Index reports whether index can be hardened, i.e. is in [0, hdkeychain.HardenedKeyStart). Larger values
+// would wrap around when converted to uint32 and derive another index's key.
+func validBIP85Index(index int) bool {
+	return index >= 0 && int64(index) < hdkeychain.HardenedKeyStart
+}
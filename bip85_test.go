@@ -0,0 +1,139 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+// master key of the BIP85 test vectors
+const bip85TestMasterKey = "xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb"
+
+func bip85TestWallet(t *testing.T) *HDWallet {
+	wallet, err := NewHDWalletFromMasterExtendedPrivateKey(bip85TestMasterKey, BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+	return wallet
+}
+
+func TestBIP85Entropy_TestVectors(t *testing.T) {
+	kf := bip85TestWallet(t).keyFactory()
+
+	entropy, err := kf.bip85Entropy(0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "efecfbccffea313214232d29e71563d941229afb4338c21f9517c41aaa0d16f00b83d2a09ef747e7a64e8e2bd5a14869e693da66ce94ac2da570ab7ee48618f7", hex.EncodeToString(entropy))
+
+	entropy, err = kf.bip85Entropy(0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "70c6e3e8ebee8dc4c0dbba66076819bb8c09672527c4277ca8729532ad711872218f826919f6b67218adde99018a6df9095ab2b58d803b5b93ec9802085a690e", hex.EncodeToString(entropy))
+}
+
+func TestBIP85Mnemonic_TestVectors(t *testing.T) {
+	wallet := bip85TestWallet(t)
+
+	words, err := wallet.BIP85Mnemonic(12, BIP39LanguageEnglish, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose", words)
+
+	words, err = wallet.BIP85Mnemonic(18, BIP39LanguageEnglish, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "near account window bike charge season chef number sketch tomorrow excuse sniff circle vital hockey outdoor supply token", words)
+
+	words, err = wallet.BIP85Mnemonic(24, BIP39LanguageEnglish, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce twin tonight reason outdoor destroy simple truth cigar social volcano", words)
+}
+
+func TestBIP85Mnemonic_Languages(t *testing.T) {
+	wallet := bip85TestWallet(t)
+
+	english, _ := wallet.BIP85Mnemonic(12, BIP39LanguageEnglish, 0)
+	japanese, err := wallet.BIP85Mnemonic(12, BIP39LanguageJapanese, 0)
+	assert.Nil(t, err)
+
+	language, err := DetectMnemonicLanguage(japanese)
+	assert.Nil(t, err)
+	assert.Equal(t, BIP39LanguageJapanese, language)
+
+	// the language is part of the path, so the entropy differs too
+	englishEntropy, _ := mnemonicEntropy(splitMnemonicForTest(english), BIP39LanguageEnglish)
+	japaneseEntropy, _ := mnemonicEntropy(splitMnemonicForTest(japanese), BIP39LanguageJapanese)
+	assert.NotEqual(t, englishEntropy, japaneseEntropy)
+
+	_, err = wallet.BIP85Mnemonic(12, "klingon", 0)
+	assert.Equal(t, ErrUnsupportedMnemonicLanguage, err)
+
+	_, err = wallet.BIP85Mnemonic(13, BIP39LanguageEnglish, 0)
+	assert.Equal(t, ErrInvalidWordCount, err)
+
+	_, err = wallet.BIP85Mnemonic(12, BIP39LanguageEnglish, -1)
+	assert.Equal(t, ErrInvalidBIP85Index, err)
+}
+
+func TestBIP85WIF_TestVector(t *testing.T) {
+	wif, err := bip85TestWallet(t).BIP85WIF(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Kzyv4uF39d4Jrw2W7UryTHwZr1zQVNk4dAFyqE6BuMrMh1Za7uhp", wif)
+}
+
+func TestBIP85ExtendedPrivateKey_TestVector(t *testing.T) {
+	key, err := bip85TestWallet(t).BIP85ExtendedPrivateKey(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "xprv9s21ZrQH143K2srSbCSg4m4kLvPMzcWydgmKEnMmoZUurYuBuYG46c6P71UGXMzmriLzCCBvKQWBUv3vPB3m1SATMhp3uEjXHJ42jFg7myX", key)
+}
+
+func TestBIP85HexEntropy_TestVector(t *testing.T) {
+	wallet := bip85TestWallet(t)
+
+	entropy, err := wallet.BIP85HexEntropy(64, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "492db4698cf3b73a5a24998aa3e9d7fa96275d85724a91e71aa2d645442f878555d078fd1f1f67e368976f04137b1f7a0d19232136ca50c44614af72b5582a5c", entropy)
+
+	_, err = wallet.BIP85HexEntropy(15, 0)
+	assert.Equal(t, ErrInvalidBIP85Index, err)
+
+	_, err = wallet.BIP85HexEntropy(65, 0)
+	assert.Equal(t, ErrInvalidBIP85Index, err)
+}
+
+func TestBIP85_RejectsIndexesOutsideHardenedRange(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	for _, index := range []int64{-1, hdkeychain.HardenedKeyStart, 1<<32 + 1} {
+		if int64(int(index)) != index {
+			continue // 1<<32 + 1 does not fit in a 32 bit int
+		}
+		_, err := wallet.BIP85Mnemonic(12, BIP39LanguageEnglish, int(index))
+		assert.Equal(t, ErrInvalidBIP85Index, err)
+		_, err = wallet.BIP85WIF(int(index))
+		assert.Equal(t, ErrInvalidBIP85Index, err)
+		_, err = wallet.BIP85ExtendedPrivateKey(int(index))
+		assert.Equal(t, ErrInvalidBIP85Index, err)
+		_, err = wallet.BIP85HexEntropy(32, int(index))
+		assert.Equal(t, ErrInvalidBIP85Index, err)
+	}
+
+	_, err := wallet.BIP85WIF(hdkeychain.HardenedKeyStart - 1)
+	assert.Nil(t, err)
+}
+
+func TestBIP85_RequiresMasterKey(t *testing.T) {
+	wallet, err := NewHDWalletFromAccountExtendedPrivateKey(bip84AccountZprv)
+	assert.Nil(t, err)
+
+	_, err = wallet.BIP85Mnemonic(12, BIP39LanguageEnglish, 0)
+	assert.Equal(t, ErrMasterKeyRequired, err)
+
+	watchOnly, err := NewHDWalletFromAccountExtendedPublicKey(bip84AccountZpub)
+	assert.Nil(t, err)
+
+	_, err = watchOnly.BIP85WIF(0)
+	assert.NotNil(t, err)
+}
+
+func splitMnemonicForTest(words string) []string {
+	return strings.Fields(norm.NFKD.String(words))
+}
@@ -0,0 +1,27 @@
+package cnlib
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+/// Type Definition
+
+// DecryptedMessage is a model object which holds a decrypted payload and the public key of the key that sent it.
+type DecryptedMessage struct {
+	Body                        []byte
+	SenderPublicKey             string // hex-encoded compressed public key, comparable to CoinNinjaVerificationKeyHexString
+	SenderUncompressedPublicKey string // hex-encoded uncompressed public key, as embedded in the payload
+}
+
+/// Constructors
+
+// newDecryptedMessage creates and returns a pointer to a DecryptedMessage object.
+func newDecryptedMessage(body []byte, sender *btcec.PublicKey) *DecryptedMessage {
+	return &DecryptedMessage{
+		Body:                        body,
+		SenderPublicKey:             hex.EncodeToString(sender.SerializeCompressed()),
+		SenderUncompressedPublicKey: hex.EncodeToString(sender.SerializeUncompressed()),
+	}
+}
@@ -15,3 +15,8 @@ func NewDerivationPath(bc *BaseCoin, change int, index int) *DerivationPath {
 		Index:    index,
 	}
 }
+
+// clone returns a copy of the path with its own copy of the BaseCoin.
+func (path *DerivationPath) clone() *DerivationPath {
+	return NewDerivationPath(path.BaseCoin.clone(), path.Change, path.Index)
+}
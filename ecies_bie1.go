@@ -0,0 +1,131 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// BIE1 is the ECIES format used by Electrum (and compatible wallets) to encrypt messages to a public key. Layout, base64 encoded:
+//
+//	magic "BIE1" (4) | ephemeral compressed public key (33) | cipher text | hmac (32)
+//
+// The key material is sha512 of the compressed ECDH point; its first 16 bytes are the AES-128-CBC iv, the next 16 the AES key,
+// and the last 32 the hmac key. The hmac covers everything before it.
+const (
+	bie1MagicSize   = 4
+	bie1PubkeySize  = 33
+	bie1MinDataSize = bie1MagicSize + bie1PubkeySize + aes.BlockSize + sha256.Size
+)
+
+var bie1Magic = []byte("BIE1")
+
+// ErrInvalidBIE1Magic describes an error in which a payload does not start with the BIE1 magic bytes.
+var ErrInvalidBIE1Magic = errors.New("invalid BIE1 magic bytes")
+
+// encryptBIE1 encrypts data to a public key using a fresh ephemeral key, returning the base64 encoded BIE1 payload.
+func encryptBIE1(data []byte, publicKey *btcec.PublicKey) (string, error) {
+	ephemeral, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return "", err
+	}
+	return encryptBIE1WithEphemeralKey(data, ephemeral, publicKey)
+}
+
+func encryptBIE1WithEphemeralKey(data []byte, ephemeral *btcec.PrivateKey, publicKey *btcec.PublicKey) (string, error) {
+	iv, encKey, hmacKey := bie1Keys(ephemeral, publicKey)
+
+	cipherBlock, err := aes.NewCipher(encKey)
+	if err != nil {
+		return "", err
+	}
+
+	// padd text for encryption
+	blockSize := cipherBlock.BlockSize()
+	padding := blockSize - len(data)%blockSize
+	cipherText := make([]byte, len(data), len(data)+padding)
+	copy(cipherText, data)
+	cipherText = append(cipherText, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	encrypter := cipher.NewCBCEncrypter(cipherBlock, iv)
+	encrypter.CryptBlocks(cipherText, cipherText)
+
+	msg := make([]byte, 0, bie1MagicSize+bie1PubkeySize+len(cipherText)+sha256.Size)
+	msg = append(msg, bie1Magic...)
+	msg = append(msg, ephemeral.PubKey().SerializeCompressed()...)
+	msg = append(msg, cipherText...)
+
+	hmacSrc := hmac.New(sha256.New, hmacKey)
+	_, err = hmacSrc.Write(msg)
+	if err != nil {
+		return "", errors.New("failed to write hmacSrc")
+	}
+	msg = append(msg, hmacSrc.Sum(nil)...)
+
+	return base64.StdEncoding.EncodeToString(msg), nil
+}
+
+// decryptBIE1 decrypts a base64 encoded BIE1 payload with the given private key.
+func decryptBIE1(payload string, privateKey *btcec.PrivateKey) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < bie1MinDataSize {
+		return nil, ErrInsufficientData
+	}
+
+	if !bytes.Equal(data[:bie1MagicSize], bie1Magic) {
+		return nil, ErrInvalidBIE1Magic
+	}
+
+	ephemeral, err := btcec.ParsePubKey(data[bie1MagicSize:bie1MagicSize+bie1PubkeySize], btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText := data[bie1MagicSize+bie1PubkeySize : len(data)-sha256.Size]
+	hmacVal := data[len(data)-sha256.Size:]
+
+	if len(cipherText)%aes.BlockSize != 0 {
+		return nil, ErrInvalidCipherTextLength
+	}
+
+	iv, encKey, hmacKey := bie1Keys(privateKey, ephemeral)
+
+	testHmac := hmac.New(sha256.New, hmacKey)
+	_, err = testHmac.Write(data[:len(data)-sha256.Size])
+	if err != nil {
+		return nil, errors.New("failed to write testHmac")
+	}
+	if verified := hmac.Equal(testHmac.Sum(nil), hmacVal); !verified {
+		return nil, ErrInvalidHMAC
+	}
+
+	cipherBlock, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]byte, len(cipherText))
+	decrypter := cipher.NewCBCDecrypter(cipherBlock, iv)
+	decrypter.CryptBlocks(decrypted, cipherText)
+
+	return unpad(decrypted, cipherBlock.BlockSize())
+}
+
+// bie1Keys derives the iv, AES-128 key and hmac key from the compressed ECDH point.
+func bie1Keys(privateKey *btcec.PrivateKey, publicKey *btcec.PublicKey) ([]byte, []byte, []byte) {
+	x, y := btcec.S256().ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+	point := btcec.PublicKey{Curve: btcec.S256(), X: x, Y: y}
+	keyData := sha512.Sum512(point.SerializeCompressed())
+	return keyData[:16], keyData[16:32], keyData[32:]
+}
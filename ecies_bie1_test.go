@@ -0,0 +1,95 @@
+package cnlib
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+// electrumTestKey is the key Electrum's test suite derives from the password "pw123".
+func electrumTestKey(t *testing.T) *btcec.PrivateKey {
+	keyBytes, err := hex.DecodeString("2db55bc2121375cef4274b59c36ac703922622527a5af5e6c8df149e3b85b0df")
+	assert.Nil(t, err)
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+	return key
+}
+
+func TestDecryptBIE1_ElectrumVectors(t *testing.T) {
+	key := electrumTestKey(t)
+	payloads := []string{
+		"QklFMQMDFtgT3zWSQsa+Uie8H/WvfUjlu9UN9OJtTt3KlgKeSTi6SQfuhcg1uIz9hp3WIUOFGTLr4RNQBdjPNqzXwhkcPi2Xsbiw6UCNJncVPJ6QBg==",
+		"QklFMQKXOXbylOQTSMGfo4MFRwivAxeEEkewWQrpdYTzjPhqjHcGBJwdIhB7DyRfRQihuXx1y0ZLLv7XxLzrILzkl/H4YUtZB4uWjuOAcmxQH4i/Og==",
+	}
+
+	for _, payload := range payloads {
+		dec, err := decryptBIE1(payload, key)
+		assert.Nil(t, err)
+		assert.Equal(t, "me<(s_s)>age", string(dec))
+	}
+}
+
+func TestDecryptBIE1_WrongKey(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+	payload := "QklFMQMDFtgT3zWSQsa+Uie8H/WvfUjlu9UN9OJtTt3KlgKeSTi6SQfuhcg1uIz9hp3WIUOFGTLr4RNQBdjPNqzXwhkcPi2Xsbiw6UCNJncVPJ6QBg=="
+
+	dec, err := decryptBIE1(payload, bob)
+	assert.Equal(t, ErrInvalidHMAC, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecryptBIE1_Malformed(t *testing.T) {
+	key := electrumTestKey(t)
+
+	dec, err := decryptBIE1("not base64!", key)
+	assert.NotNil(t, err)
+	assert.Nil(t, dec)
+
+	dec, err = decryptBIE1(base64.StdEncoding.EncodeToString([]byte("BIE1")), key)
+	assert.Equal(t, ErrInsufficientData, err)
+	assert.Nil(t, dec)
+
+	data, err := base64.StdEncoding.DecodeString("QklFMQMDFtgT3zWSQsa+Uie8H/WvfUjlu9UN9OJtTt3KlgKeSTi6SQfuhcg1uIz9hp3WIUOFGTLr4RNQBdjPNqzXwhkcPi2Xsbiw6UCNJncVPJ6QBg==")
+	assert.Nil(t, err)
+	data[3] = '2'
+	dec, err = decryptBIE1(base64.StdEncoding.EncodeToString(data), key)
+	assert.Equal(t, ErrInvalidBIE1Magic, err)
+	assert.Nil(t, dec)
+}
+
+func TestEncryptMessageBIE1_WalletEndToEnd(t *testing.T) {
+	messageString := "hey dude"
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessageBIE1([]byte(messageString), bobCPK)
+	assert.Nil(t, err)
+
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, "BIE1", string(raw[:4]))
+
+	dec, err := bobWallet.DecryptMessageBIE1(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec))
+}
+
+func TestEncryptMessageBIE1_AddressKey(t *testing.T) {
+	messageString := "hey dude"
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 7)
+	pubkey, err := bobWallet.CompressedPubKeyForPath(path)
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessageBIE1([]byte(messageString), hex.EncodeToString(pubkey))
+	assert.Nil(t, err)
+
+	dec, err := bobWallet.DecryptBIE1WithKeyFromDerivationPath(path, enc)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec))
+}
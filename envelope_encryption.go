@@ -0,0 +1,304 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+)
+
+// An envelope encrypts a body once under a random content key, and wraps the content key for each recipient using the same
+// ECDH derivation as the v3 payload. Layout:
+//
+//	version (1) | options (1) | recipient count (2, big endian)
+//	recipient count * [ recipient id (20) | wrap iv (16) | wrapped content key (32) | wrap hmac (32) ]
+//	iv (16) | cipher text | hmac (32) | sender uncompressed public key (65)
+//
+// The recipient id is the Hash160 of the recipient's compressed public key. Each wrap hmac is keyed by the sender/recipient
+// ECDH secret and covers the header, the recipient's entry, the iv and the cipher text, so a recipient can verify the body came
+// from the sender even though every recipient knows the content key. The trailing hmac is keyed by the content key and covers
+// everything before it.
+const (
+	envelopeVersion        = byte(4)
+	envelopeHeaderSize     = 4
+	envelopeRecipientIDLen = 20
+	envelopeContentKeyLen  = 32
+	envelopeEntrySize      = envelopeRecipientIDLen + aes.BlockSize + envelopeContentKeyLen + sha256.Size
+	minEnvelopeSize        = envelopeHeaderSize + envelopeEntrySize + aes.BlockSize + aes.BlockSize + sha256.Size + 65
+)
+
+var (
+	// ErrNoRecipients describes an error in which an envelope was requested without any recipient public keys.
+	ErrNoRecipients = errors.New("no recipient public keys provided")
+
+	// ErrTooManyRecipients describes an error in which more recipients were requested than an envelope can hold.
+	ErrTooManyRecipients = errors.New("too many recipient public keys")
+
+	// ErrRecipientNotFound describes an error in which the decrypting key is not one of the envelope's recipients.
+	ErrRecipientNotFound = errors.New("no wrapped key found for recipient")
+)
+
+// encryptEnvelope encrypts data once under a random content key, and wraps that key for each recipient public key.
+func encryptEnvelope(data []byte, privateKey *btcec.PrivateKey, recipients []*btcec.PublicKey) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	if len(recipients) > math.MaxUint16 {
+		return nil, ErrTooManyRecipients
+	}
+
+	contentKey, err := randBytes(envelopeContentKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	contentKeyData := sha512.Sum512(contentKey)
+	encKey := contentKeyData[:32]
+	hmacKey := contentKeyData[32:]
+
+	iv, err := randBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherBlock, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// padd text for encryption
+	blockSize := cipherBlock.BlockSize()
+	padding := blockSize - len(data)%blockSize
+	cipherText := make([]byte, len(data), len(data)+padding)
+	copy(cipherText, data)
+	cipherText = append(cipherText, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	encrypter := cipher.NewCBCEncrypter(cipherBlock, iv)
+	encrypter.CryptBlocks(cipherText, cipherText)
+
+	header := make([]byte, envelopeHeaderSize)
+	header[0] = envelopeVersion
+	header[1] = byte(0)
+	binary.BigEndian.PutUint16(header[2:], uint16(len(recipients)))
+
+	msg := make([]byte, 0, len(header)+len(recipients)*envelopeEntrySize+len(iv)+len(cipherText)+sha256.Size+65)
+	msg = append(msg, header...)
+
+	for _, recipient := range recipients {
+		entry, err := wrapContentKey(contentKey, header, iv, cipherText, privateKey, recipient)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, entry...)
+	}
+
+	msg = append(msg, iv...)
+	msg = append(msg, cipherText...)
+
+	hmacSrc := hmac.New(sha256.New, hmacKey)
+	_, err = hmacSrc.Write(msg)
+	if err != nil {
+		return nil, errors.New("failed to write hmacSrc")
+	}
+	msg = append(msg, hmacSrc.Sum(nil)...)
+
+	msg = append(msg, privateKey.PubKey().SerializeUncompressed()...)
+
+	return msg, nil
+}
+
+// decryptEnvelope locates the wrapped content key for the given private key, and decrypts the envelope body.
+func decryptEnvelope(data []byte, privateKey *btcec.PrivateKey) ([]byte, *btcec.PublicKey, error) {
+	if len(data) < minEnvelopeSize {
+		return nil, nil, ErrInsufficientData
+	}
+
+	header := data[:envelopeHeaderSize]
+	if header[0] != envelopeVersion {
+		return nil, nil, ErrInvalidPayloadVersion
+	}
+	if header[1] != byte(0) {
+		return nil, nil, ErrInvalidPayloadOption
+	}
+
+	count := int(binary.BigEndian.Uint16(header[2:]))
+	if count == 0 {
+		return nil, nil, ErrNoRecipients
+	}
+
+	entriesEnd := envelopeHeaderSize + count*envelopeEntrySize
+	if len(data) < entriesEnd+aes.BlockSize+aes.BlockSize+sha256.Size+65 {
+		return nil, nil, ErrInsufficientData
+	}
+
+	entries := data[envelopeHeaderSize:entriesEnd]
+	iv := data[entriesEnd : entriesEnd+aes.BlockSize]
+	cipherText := data[entriesEnd+aes.BlockSize : len(data)-sha256.Size-65]
+	hmacVal := data[len(data)-sha256.Size-65 : len(data)-65]
+	publicKeyUncomp := data[len(data)-65:]
+
+	if len(cipherText) == 0 || len(cipherText)%aes.BlockSize != 0 {
+		return nil, nil, ErrInvalidCipherTextLength
+	}
+
+	sender, err := btcec.ParsePubKey(publicKeyUncomp, btcec.S256())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recipientID := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+	var entry []byte
+	for i := 0; i < count; i++ {
+		candidate := entries[i*envelopeEntrySize : (i+1)*envelopeEntrySize]
+		if bytes.Equal(candidate[:envelopeRecipientIDLen], recipientID) {
+			entry = candidate
+			break
+		}
+	}
+	if entry == nil {
+		return nil, nil, ErrRecipientNotFound
+	}
+
+	contentKey, err := unwrapContentKey(entry, header, iv, cipherText, privateKey, sender)
+	if err != nil {
+		return nil, nil, err
+	}
+	contentKeyData := sha512.Sum512(contentKey)
+	encKey := contentKeyData[:32]
+	hmacKey := contentKeyData[32:]
+
+	testHmac := hmac.New(sha256.New, hmacKey)
+	_, err = testHmac.Write(data[:len(data)-sha256.Size-65])
+	if err != nil {
+		return nil, nil, errors.New("failed to write testHmac")
+	}
+	if verified := hmac.Equal(testHmac.Sum(nil), hmacVal); !verified {
+		return nil, nil, ErrInvalidHMAC
+	}
+
+	cipherBlock, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decrypted := make([]byte, len(cipherText))
+	copy(decrypted, cipherText)
+	decrypter := cipher.NewCBCDecrypter(cipherBlock, iv)
+	decrypter.CryptBlocks(decrypted, decrypted)
+
+	unpadded, err := unpad(decrypted, cipherBlock.BlockSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return unpadded, sender, nil
+}
+
+// wrapContentKey encrypts the content key to a single recipient, returning the recipient's envelope entry.
+func wrapContentKey(contentKey []byte, header []byte, iv []byte, cipherText []byte, privateKey *btcec.PrivateKey, recipient *btcec.PublicKey) ([]byte, error) {
+	secret := generateSharedSecretRFC4753(privateKey, recipient)
+	keyData := sha512.Sum512(secret)
+
+	wrapIV, err := randBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherBlock, err := aes.NewCipher(keyData[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	// the content key is a whole number of blocks, so it needs no padding
+	wrapped := make([]byte, len(contentKey))
+	encrypter := cipher.NewCBCEncrypter(cipherBlock, wrapIV)
+	encrypter.CryptBlocks(wrapped, contentKey)
+
+	entry := make([]byte, 0, envelopeEntrySize)
+	entry = append(entry, btcutil.Hash160(recipient.SerializeCompressed())...)
+	entry = append(entry, wrapIV...)
+	entry = append(entry, wrapped...)
+
+	mac, err := envelopeEntryHMAC(keyData[32:], header, entry, iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(entry, mac...), nil
+}
+
+// unwrapContentKey authenticates a recipient's envelope entry and decrypts its content key.
+func unwrapContentKey(entry []byte, header []byte, iv []byte, cipherText []byte, privateKey *btcec.PrivateKey, sender *btcec.PublicKey) ([]byte, error) {
+	secret := generateSharedSecretRFC4753(privateKey, sender)
+	keyData := sha512.Sum512(secret)
+
+	body := entry[:envelopeEntrySize-sha256.Size]
+	wrapIV := body[envelopeRecipientIDLen : envelopeRecipientIDLen+aes.BlockSize]
+	wrapped := body[envelopeRecipientIDLen+aes.BlockSize:]
+
+	mac, err := envelopeEntryHMAC(keyData[32:], header, body, iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	if verified := hmac.Equal(mac, entry[envelopeEntrySize-sha256.Size:]); !verified {
+		return nil, ErrInvalidHMAC
+	}
+
+	cipherBlock, err := aes.NewCipher(keyData[:32])
+	if err != nil {
+		return nil, err
+	}
+
+	contentKey := make([]byte, len(wrapped))
+	decrypter := cipher.NewCBCDecrypter(cipherBlock, wrapIV)
+	decrypter.CryptBlocks(contentKey, wrapped)
+
+	return contentKey, nil
+}
+
+func envelopeEntryHMAC(hmacKey []byte, header []byte, entry []byte, iv []byte, cipherText []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, hmacKey)
+	for _, part := range [][]byte{header, entry, iv, cipherText} {
+		if _, err := mac.Write(part); err != nil {
+			return nil, errors.New("failed to write entry hmac")
+		}
+	}
+	return mac.Sum(nil), nil
+}
+
+// parseRecipientPublicKeys parses a space-separated list of hex-encoded public keys, dropping duplicates.
+func parseRecipientPublicKeys(recipientPubkeys string) ([]*btcec.PublicKey, error) {
+	keys := make([]*btcec.PublicKey, 0)
+	seen := make(map[string]bool)
+	for _, field := range strings.Fields(recipientPubkeys) {
+		pubkeyBytes, err := hex.DecodeString(field)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := btcec.ParsePubKey(pubkeyBytes, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+
+		id := string(publicKey.SerializeCompressed())
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, publicKey)
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoRecipients
+	}
+	return keys, nil
+}
@@ -0,0 +1,141 @@
+package cnlib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bobWords   = "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"
+	carolWords = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+)
+
+func TestEncryptMessageForRecipients_EachRecipientCanDecrypt(t *testing.T) {
+	messageString := "split the check three ways"
+
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	carolWallet := NewHDWalletFromWords(carolWords, BaseCoinBip84MainNet)
+
+	aliceCPK, err := aliceWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	carolPath := NewDerivationPath(BaseCoinBip84MainNet, 0, 3)
+	carolAddr, err := carolWallet.ReceiveAddressForIndex(3)
+	assert.Nil(t, err)
+
+	recipients := strings.Join([]string{aliceCPK, bobCPK, carolAddr.UncompressedPublicKey}, " ")
+	enc, err := aliceWallet.EncryptMessageForRecipients([]byte(messageString), recipients)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(3), binary.BigEndian.Uint16(enc[2:4]))
+
+	dec, err := aliceWallet.DecryptEnvelope(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec.Body))
+	assert.Equal(t, aliceCPK, dec.SenderPublicKey)
+
+	dec, err = bobWallet.DecryptEnvelope(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec.Body))
+	assert.Equal(t, aliceCPK, dec.SenderPublicKey)
+
+	dec, err = carolWallet.DecryptEnvelopeWithKeyFromDerivationPath(carolPath, enc)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec.Body))
+	assert.Equal(t, aliceCPK, dec.SenderPublicKey)
+}
+
+func TestEncryptMessageForRecipients_DuplicateRecipients(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	bobKey, err := bobWallet.signingPrivateKey()
+	assert.Nil(t, err)
+	bobUCPK := hex.EncodeToString(bobKey.PubKey().SerializeUncompressed())
+
+	enc, err := aliceWallet.EncryptMessageForRecipients([]byte("hey dude"), bobCPK+" "+bobUCPK)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(enc[2:4]))
+}
+
+func TestEncryptMessageForRecipients_NoRecipients(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	enc, err := aliceWallet.EncryptMessageForRecipients([]byte("hey dude"), "  ")
+	assert.Equal(t, ErrNoRecipients, err)
+	assert.Nil(t, enc)
+}
+
+func TestDecryptEnvelope_NotARecipient(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	carolWallet := NewHDWalletFromWords(carolWords, BaseCoinBip84MainNet)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessageForRecipients([]byte("hey dude"), bobCPK)
+	assert.Nil(t, err)
+
+	dec, err := carolWallet.DecryptEnvelope(enc)
+	assert.Equal(t, ErrRecipientNotFound, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecryptEnvelope_TamperedCipherText(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessageForRecipients([]byte("hey dude"), bobCPK)
+	assert.Nil(t, err)
+
+	enc[len(enc)-65-32-1] ^= 0xff
+	dec, err := bobWallet.DecryptEnvelope(enc)
+	assert.Equal(t, ErrInvalidHMAC, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecryptEnvelope_RecipientCannotForgeBodyForOthers(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	carolWallet := NewHDWalletFromWords(carolWords, BaseCoinBip84MainNet)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	carolCPK, err := carolWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessageForRecipients([]byte("pay bob 1000 sats"), bobCPK+" "+carolCPK)
+	assert.Nil(t, err)
+
+	// bob learns the content key, alters the cipher text and re-seals the outer hmac
+	bobKey, err := bobWallet.signingPrivateKey()
+	assert.Nil(t, err)
+	aliceKey, err := aliceWallet.signingPrivateKey()
+	assert.Nil(t, err)
+	entry := enc[envelopeHeaderSize : envelopeHeaderSize+envelopeEntrySize]
+	cipherStart := envelopeHeaderSize + 2*envelopeEntrySize + 16
+	contentKey, err := unwrapContentKey(entry, enc[:envelopeHeaderSize], enc[cipherStart-16:cipherStart], enc[cipherStart:len(enc)-32-65], bobKey, aliceKey.PubKey())
+	assert.Nil(t, err)
+
+	forged := append([]byte{}, enc...)
+	forged[cipherStart] ^= 0x01
+	keyData := sha512.Sum512(contentKey)
+	mac := hmac.New(sha256.New, keyData[32:])
+	_, err = mac.Write(forged[:len(forged)-32-65])
+	assert.Nil(t, err)
+	copy(forged[len(forged)-32-65:], mac.Sum(nil))
+
+	dec, err := carolWallet.DecryptEnvelope(forged)
+	assert.Equal(t, ErrInvalidHMAC, err)
+	assert.Nil(t, dec)
+}
@@ -0,0 +1,212 @@
+package cnlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const (
+	xprv = "xprv"
+	yprv = "yprv"
+	zprv = "zprv"
+	tprv = "tprv"
+	uprv = "uprv"
+	vprv = "vprv"
+
+	extendedKeySize = 78 // version(4) | depth(1) | parent fingerprint(4) | child number(4) | chain code(32) | key(33)
+)
+
+var privkeyIDs = map[string][]byte{
+	xprv: []byte{0x04, 0x88, 0xad, 0xe4}, // m/44'/0'
+	yprv: []byte{0x04, 0x9d, 0x78, 0x78}, // m/49'/0'
+	zprv: []byte{0x04, 0xb2, 0x43, 0x0c}, // m/84'/0'
+	tprv: []byte{0x04, 0x35, 0x83, 0x94}, // m/44'/1'
+	uprv: []byte{0x04, 0x4a, 0x4e, 0x28}, // m/49'/1'
+	vprv: []byte{0x04, 0x5f, 0x18, 0xbc}, // m/84'/1'
+}
+
+// slip132Prefixes describes each SLIP-132 prefix, and the purpose and coin it stands for.
+var slip132Prefixes = map[string]struct {
+	private bool
+	purpose int
+	coin    int
+}{
+	xpub: {false, bip44purpose, mainnet},
+	ypub: {false, bip49purpose, mainnet},
+	zpub: {false, bip84purpose, mainnet},
+	tpub: {false, bip44purpose, testnet},
+	upub: {false, bip49purpose, testnet},
+	vpub: {false, bip84purpose, testnet},
+	xprv: {true, bip44purpose, mainnet},
+	yprv: {true, bip49purpose, mainnet},
+	zprv: {true, bip84purpose, mainnet},
+	tprv: {true, bip44purpose, testnet},
+	uprv: {true, bip49purpose, testnet},
+	vprv: {true, bip84purpose, testnet},
+}
+
+var (
+	// ErrInvalidExtendedKey describes an error in which an extended key's encoding, depth, child number or key data is invalid.
+	ErrInvalidExtendedKey = errors.New("invalid extended key")
+
+	// ErrUnknownExtendedKeyPrefix describes an error in which an extended key's version bytes are not a known SLIP-132 prefix.
+	ErrUnknownExtendedKeyPrefix = errors.New("unknown extended key prefix")
+
+	// ErrExtendedKeyNetworkMismatch describes an error in which a conversion would move a key to another network.
+	ErrExtendedKeyNetworkMismatch = errors.New("extended key conversion between networks")
+
+	// ErrPublicToPrivateConversion describes an error in which a public extended key was asked to convert to a private prefix.
+	ErrPublicToPrivateConversion = errors.New("cannot convert a public extended key to a private one")
+)
+
+/// Type Definition
+
+// slip132Key is a decoded and validated extended key.
+type slip132Key struct {
+	prefix      string
+	depth       uint8
+	parentFP    []byte
+	childNumber uint32
+	chainCode   []byte
+	keyData     []byte
+}
+
+/// Package functions
+
+// ExtendedKeyPrefix validates an extended key and returns its SLIP-132 prefix, e.g. "zpub".
+func ExtendedKeyPrefix(key string) (string, error) {
+	parsed, err := parseSLIP132Key(key)
+	if err != nil {
+		return "", err
+	}
+	return parsed.prefix, nil
+}
+
+// ConvertExtendedKey re-encodes an extended key with another SLIP-132 prefix on the same network, e.g. xpub to zpub or
+// zprv to yprv. A private key may also be converted to any public prefix. The key is fully validated first.
+func ConvertExtendedKey(key string, targetPrefix string) (string, error) {
+	parsed, err := parseSLIP132Key(key)
+	if err != nil {
+		return "", err
+	}
+
+	target, ok := slip132Prefixes[targetPrefix]
+	if !ok {
+		return "", ErrUnknownExtendedKeyPrefix
+	}
+	source := slip132Prefixes[parsed.prefix]
+
+	if source.coin != target.coin {
+		return "", ErrExtendedKeyNetworkMismatch
+	}
+	if !source.private && target.private {
+		return "", ErrPublicToPrivateConversion
+	}
+
+	keyData := parsed.keyData
+	if source.private && !target.private {
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyData[1:])
+		keyData = privKey.PubKey().SerializeCompressed()
+	}
+
+	converted := &slip132Key{
+		prefix:      targetPrefix,
+		depth:       parsed.depth,
+		parentFP:    parsed.parentFP,
+		childNumber: parsed.childNumber,
+		chainCode:   parsed.chainCode,
+		keyData:     keyData,
+	}
+	return converted.String(), nil
+}
+
+/// Receiver methods
+
+// String returns the base58check serialization of the key.
+func (k *slip132Key) String() string {
+	serialized := make([]byte, 0, extendedKeySize)
+	serialized = append(serialized, slip132Version(k.prefix)...)
+	serialized = append(serialized, k.depth)
+	serialized = append(serialized, k.parentFP...)
+	serialized = append(serialized, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(serialized[len(serialized)-4:], k.childNumber)
+	serialized = append(serialized, k.chainCode...)
+	serialized = append(serialized, k.keyData...)
+	return base58.CheckEncode(serialized[1:], serialized[0])
+}
+
+// isAccountKey returns whether the key sits at a hardened depth 3 child, i.e. m/purpose'/coin'/account'.
+func (k *slip132Key) isAccountKey() bool {
+	return k.depth == 3 && k.childNumber >= hdkeychain.HardenedKeyStart
+}
+
+/// Unexported functions
+
+// parseSLIP132Key decodes an extended key and validates its version, depth, parent fingerprint, child number and key data.
+func parseSLIP132Key(key string) (*slip132Key, error) {
+	payload, version, err := base58.CheckDecode(key)
+	if err != nil {
+		return nil, ErrInvalidExtendedKey
+	}
+	serialized := append([]byte{version}, payload...)
+	if len(serialized) != extendedKeySize {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	prefix := ""
+	for name := range slip132Prefixes {
+		if bytes.Equal(serialized[:4], slip132Version(name)) {
+			prefix = name
+			break
+		}
+	}
+	if prefix == "" {
+		return nil, ErrUnknownExtendedKeyPrefix
+	}
+
+	parsed := &slip132Key{
+		prefix:      prefix,
+		depth:       serialized[4],
+		parentFP:    serialized[5:9],
+		childNumber: binary.BigEndian.Uint32(serialized[9:13]),
+		chainCode:   serialized[13:45],
+		keyData:     serialized[45:78],
+	}
+
+	// a master key has no parent and is not a child
+	if parsed.depth == 0 && (!bytes.Equal(parsed.parentFP, []byte{0, 0, 0, 0}) || parsed.childNumber != 0) {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	if slip132Prefixes[prefix].private {
+		if parsed.keyData[0] != 0x00 {
+			return nil, ErrInvalidExtendedKey
+		}
+		d := new(big.Int).SetBytes(parsed.keyData[1:])
+		if d.Sign() == 0 || d.Cmp(btcec.S256().N) >= 0 {
+			return nil, ErrInvalidExtendedKey
+		}
+	} else {
+		if parsed.keyData[0] != 0x02 && parsed.keyData[0] != 0x03 {
+			return nil, ErrInvalidExtendedKey
+		}
+		if _, err := btcec.ParsePubKey(parsed.keyData, btcec.S256()); err != nil {
+			return nil, ErrInvalidExtendedKey
+		}
+	}
+
+	return parsed, nil
+}
+
+func slip132Version(prefix string) []byte {
+	if version, ok := pubkeyIDs[prefix]; ok {
+		return version
+	}
+	return privkeyIDs[prefix]
+}
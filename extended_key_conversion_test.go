@@ -0,0 +1,139 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bip84AccountZprv  = "zprvAdG4iTXWBoARxkkzNpNh8r6Qag3irQB8PzEMkAFeTRXxHpbF9z4QgEvBRmfvqWvGp42t42nvgGpNgYSJA9iefm1yYNZKEm7z6qUWCroSQnE"
+	bip84AccountZpub  = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	bip84AccountXpub  = "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+	bip84AccountXprv  = "xprv9ybY78BftS5UGANki6oSifuQEjkpyAC8ZmBvBNTshQnCBcxnefjHS7buPMkkqhcRzmoGZ5bokx7GuyDAiktd5HemohAU4wV1ZPMDRmLpBMm"
+	abandonMasterXprv = "xprv9s21ZrQH143K3GJpoapnV8SFfukcVBSfeCficPSGfubmSFDxo1kuHnLisriDvSnRRuL2Qrg5ggqHKNVpxR86QEC8w35uxmGoggxtQTPvfUu"
+)
+
+func TestConvertExtendedKey_PublicPrefixes(t *testing.T) {
+	xpubKey, err := ConvertExtendedKey(bip84AccountZpub, xpub)
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountXpub, xpubKey)
+
+	zpubKey, err := ConvertExtendedKey(xpubKey, zpub)
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZpub, zpubKey)
+}
+
+func TestConvertExtendedKey_PrivatePrefixes(t *testing.T) {
+	xprvKey, err := ConvertExtendedKey(bip84AccountZprv, xprv)
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountXprv, xprvKey)
+
+	zprvKey, err := ConvertExtendedKey(xprvKey, zprv)
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZprv, zprvKey)
+}
+
+func TestConvertExtendedKey_PrivateToPublicNeuters(t *testing.T) {
+	zpubKey, err := ConvertExtendedKey(bip84AccountZprv, zpub)
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZpub, zpubKey)
+
+	xpubKey, err := ConvertExtendedKey(bip84AccountZprv, xpub)
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountXpub, xpubKey)
+}
+
+func TestConvertExtendedKey_SamePrefixRoundTrips(t *testing.T) {
+	key, err := ConvertExtendedKey(abandonMasterXprv, xprv)
+	assert.Nil(t, err)
+	assert.Equal(t, abandonMasterXprv, key)
+}
+
+func TestConvertExtendedKey_PublicToPrivateFails(t *testing.T) {
+	key, err := ConvertExtendedKey(bip84AccountZpub, zprv)
+	assert.Equal(t, ErrPublicToPrivateConversion, err)
+	assert.Equal(t, "", key)
+}
+
+func TestConvertExtendedKey_CrossNetworkFails(t *testing.T) {
+	_, err := ConvertExtendedKey(bip84AccountZpub, vpub)
+	assert.Equal(t, ErrExtendedKeyNetworkMismatch, err)
+
+	_, err = ConvertExtendedKey(bip84AccountZprv, tprv)
+	assert.Equal(t, ErrExtendedKeyNetworkMismatch, err)
+}
+
+func TestConvertExtendedKey_UnknownTargetPrefixFails(t *testing.T) {
+	_, err := ConvertExtendedKey(bip84AccountZpub, "Zpub")
+	assert.Equal(t, ErrUnknownExtendedKeyPrefix, err)
+}
+
+func TestConvertExtendedKey_BadChecksumFails(t *testing.T) {
+	tampered := bip84AccountZpub[:len(bip84AccountZpub)-1] + "t"
+	_, err := ConvertExtendedKey(tampered, xpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+}
+
+func TestConvertExtendedKey_MasterWithParentFails(t *testing.T) {
+	parsed, err := parseSLIP132Key(abandonMasterXprv)
+	assert.Nil(t, err)
+
+	parsed.parentFP = []byte{0xde, 0xad, 0xbe, 0xef}
+	_, err = ConvertExtendedKey(parsed.String(), xpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+
+	parsed.parentFP = []byte{0, 0, 0, 0}
+	parsed.childNumber = 1
+	_, err = ConvertExtendedKey(parsed.String(), xpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+}
+
+func TestConvertExtendedKey_InvalidKeyDataFails(t *testing.T) {
+	parsed, err := parseSLIP132Key(bip84AccountZprv)
+	assert.Nil(t, err)
+
+	// zero scalar
+	parsed.keyData = make([]byte, 33)
+	_, err = ConvertExtendedKey(parsed.String(), zpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+
+	// scalar at the curve order
+	parsed.keyData = append([]byte{0x00}, btcec.S256().N.Bytes()...)
+	_, err = ConvertExtendedKey(parsed.String(), zpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+
+	pub, err := parseSLIP132Key(bip84AccountZpub)
+	assert.Nil(t, err)
+
+	// uncompressed header byte
+	pub.keyData = append([]byte{0x04}, pub.keyData[1:]...)
+	_, err = ConvertExtendedKey(pub.String(), xpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+}
+
+func TestExtendedKeyPrefix(t *testing.T) {
+	prefix, err := ExtendedKeyPrefix(bip84AccountZprv)
+	assert.Nil(t, err)
+	assert.Equal(t, zprv, prefix)
+
+	prefix, err = ExtendedKeyPrefix(bip84AccountXpub)
+	assert.Nil(t, err)
+	assert.Equal(t, xpub, prefix)
+}
+
+func TestNewBaseCoinFromAccountPubKey_RejectsNonAccountKey(t *testing.T) {
+	masterXpub, err := ConvertExtendedKey(abandonMasterXprv, xpub)
+	assert.Nil(t, err)
+
+	bc, err := NewBaseCoinFromAccountPubKey(masterXpub)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+	assert.Nil(t, bc)
+}
+
+func TestNewBaseCoinFromAccountPubKey_RejectsPrivateKey(t *testing.T) {
+	bc, err := NewBaseCoinFromAccountPubKey(bip84AccountZprv)
+	assert.NotNil(t, err)
+	assert.Nil(t, bc)
+}
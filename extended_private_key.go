@@ -0,0 +1,122 @@
+package cnlib
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+var (
+	// ErrMasterKeyRequired describes an error in which an operation, such as deriving the m/42 identity key, needs the
+	// master private key but the wallet was created from an account-level key.
+	ErrMasterKeyRequired = errors.New("operation requires the master private key")
+
+	// ErrPathOutsideAccount describes an error in which a wallet created from an account extended private key was asked
+	// for a key outside of that account.
+	ErrPathOutsideAccount = errors.New("derivation path is outside of the wallet's account")
+
+	// ErrExpectedPrivateExtendedKey describes an error in which a public extended key was passed where a private one is needed.
+	ErrExpectedPrivateExtendedKey = errors.New("expected an extended private key")
+)
+
+/// Constructors
+
+// NewHDWalletFromMasterExtendedPrivateKey returns a pointer to an HDWallet with full spending and identity capability,
+// created from a master (depth 0) extended private key. The key's prefix only selects the network, which must match the
+// BaseCoin's, since a master key is shared by every purpose.
+func NewHDWalletFromMasterExtendedPrivateKey(key string, basecoin *BaseCoin) (*HDWallet, error) {
+	if basecoin == nil {
+		return nil, errors.New("no basecoin provided")
+	}
+
+	parsed, err := parseSLIP132Key(key)
+	if err != nil {
+		return nil, err
+	}
+	info := slip132Prefixes[parsed.prefix]
+	if !info.private {
+		return nil, ErrExpectedPrivateExtendedKey
+	}
+	if parsed.depth != 0 {
+		return nil, ErrInvalidExtendedKey
+	}
+	if info.coin != basecoin.Coin {
+		return nil, ErrExtendedKeyNetworkMismatch
+	}
+
+	masterKey, err := standardExtendedPrivateKey(key, info.coin)
+	if err != nil {
+		return nil, err
+	}
+
+	return newHDWalletFromMasterKey(masterKey, "", basecoin)
+}
+
+// NewHDWalletFromAccountExtendedPrivateKey returns a pointer to an HDWallet that can spend from a single account, created
+// from an account-level (depth 3) x/y/zprv. The prefix sets the BaseCoin as with NewHDWalletFromAccountExtendedPublicKey.
+// Keys outside that account, including the m/42 identity key, cannot be derived and return ErrPathOutsideAccount or
+// ErrMasterKeyRequired.
+func NewHDWalletFromAccountExtendedPrivateKey(key string) (*HDWallet, error) {
+	parsed, err := parseSLIP132Key(key)
+	if err != nil {
+		return nil, err
+	}
+	info := slip132Prefixes[parsed.prefix]
+	if !info.private {
+		return nil, ErrExpectedPrivateExtendedKey
+	}
+	basecoin, err := baseCoinFromAccountKey(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	accountKey, err := standardExtendedPrivateKey(key, info.coin)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := accountKey.Neuter()
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := HDWallet{
+		baseCoin:          basecoin.clone(),
+		WalletWords:       "",
+		masterPrivateKey:  nil,
+		accountPrivateKey: accountKey,
+		accountCoin:       basecoin,
+		accountPublicKey:  pubkey,
+		keyCache:          newKeyCache(),
+	}
+	return &wallet, nil
+}
+
+/// Unexported functions
+
+// baseCoinFromAccountKey returns the BaseCoin described by an account-level key's prefix and child number.
+func baseCoinFromAccountKey(parsed *slip132Key) (*BaseCoin, error) {
+	if !parsed.isAccountKey() {
+		return nil, ErrInvalidExtendedKey
+	}
+	info := slip132Prefixes[parsed.prefix]
+	acct := int(parsed.childNumber - hdkeychain.HardenedKeyStart)
+	return &BaseCoin{Purpose: info.purpose, Coin: info.coin, Account: acct}, nil
+}
+
+// standardExtendedPrivateKey parses a private key as xprv/tprv, since hdkeychain can only neuter registered versions.
+func standardExtendedPrivateKey(key string, coin int) (*hdkeychain.ExtendedKey, error) {
+	prefix := xprv
+	if coin == testnet {
+		prefix = tprv
+	}
+	converted, err := ConvertExtendedKey(key, prefix)
+	if err != nil {
+		return nil, err
+	}
+	extendedKey, err := hdkeychain.NewKeyFromString(converted)
+	if err != nil {
+		return nil, err
+	}
+	primePublicKey(extendedKey)
+	return extendedKey, nil
+}
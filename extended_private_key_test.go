@@ -0,0 +1,147 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func accountExtendedPrivateKeyForTest(t *testing.T, wallet *HDWallet, prefix string) string {
+	acctKey, err := wallet.keyFactory().accountPrivateKey(wallet.coin())
+	assert.Nil(t, err)
+	key, err := ConvertExtendedKey(acctKey.String(), prefix)
+	assert.Nil(t, err)
+	return key
+}
+
+func bip49TransactionDataForTest(t *testing.T) *TransactionData {
+	inputPath := NewDerivationPath(BaseCoinBip49MainNet, 1, 53)
+	utxo := NewUTXO("1a08dafe993fdc17fdc661988c88f97a9974013291e759b9b5766b8e97c78f87", 1, 2788424, inputPath, nil, true)
+	changePath := NewDerivationPath(BaseCoinBip49MainNet, 1, 56)
+	data := NewTransactionDataFlatFee("3BgxxADLtnoKu9oytQiiVzYUqvo8weCVy9", BaseCoinBip49MainNet, 13584, 3000, changePath, 539943)
+	data.AddUTXO(utxo)
+	assert.Nil(t, data.Generate())
+	return data.TransactionData
+}
+
+func TestNewHDWalletFromMasterExtendedPrivateKey(t *testing.T) {
+	wallet, err := NewHDWalletFromMasterExtendedPrivateKey(abandonMasterXprv, BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+
+	words := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	expected, _ := words.ReceiveAddressForIndex(0)
+	actual, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Address, actual.Address)
+
+	acctKey, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZpub, acctKey)
+
+	fingerprint, err := wallet.MasterFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a", fingerprint)
+
+	expectedKey, _ := words.CoinNinjaVerificationKeyHexString()
+	actualKey, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedKey, actualKey)
+}
+
+func TestNewHDWalletFromMasterExtendedPrivateKey_BuildsTransaction(t *testing.T) {
+	words := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+	wallet, err := NewHDWalletFromMasterExtendedPrivateKey(words.masterPrivateKey.String(), BaseCoinBip49MainNet)
+	assert.Nil(t, err)
+
+	expected, err := words.BuildTransactionMetadata(bip49TransactionDataForTest(t))
+	assert.Nil(t, err)
+	actual, err := wallet.BuildTransactionMetadata(bip49TransactionDataForTest(t))
+	assert.Nil(t, err)
+	assert.Equal(t, expected.EncodedTx, actual.EncodedTx)
+}
+
+func TestNewHDWalletFromMasterExtendedPrivateKey_Rejects(t *testing.T) {
+	_, err := NewHDWalletFromMasterExtendedPrivateKey(abandonMasterXprv, BaseCoinBip84TestNet)
+	assert.Equal(t, ErrExtendedKeyNetworkMismatch, err)
+
+	_, err = NewHDWalletFromMasterExtendedPrivateKey(bip84AccountZprv, BaseCoinBip84MainNet)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+
+	_, err = NewHDWalletFromMasterExtendedPrivateKey(bip84AccountZpub, BaseCoinBip84MainNet)
+	assert.Equal(t, ErrExpectedPrivateExtendedKey, err)
+}
+
+func TestNewHDWalletFromAccountExtendedPrivateKey(t *testing.T) {
+	wallet, err := NewHDWalletFromAccountExtendedPrivateKey(bip84AccountZprv)
+	assert.Nil(t, err)
+	assert.Equal(t, BaseCoinBip84MainNet, wallet.BaseCoin())
+
+	words := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	for _, change := range []int{0, 1} {
+		expected, _ := words.addressForIndex(words.coin(), change, 3)
+		actual, err := wallet.addressForIndex(wallet.coin(), change, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Address, actual.Address)
+	}
+
+	acctKey, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZpub, acctKey)
+
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 7)
+	expectedKey, _ := words.CompressedPubKeyForPath(path)
+	actualKey, err := wallet.CompressedPubKeyForPath(path)
+	assert.Nil(t, err)
+	assert.Equal(t, hex.EncodeToString(expectedKey), hex.EncodeToString(actualKey))
+}
+
+func TestNewHDWalletFromAccountExtendedPrivateKey_BuildsTransaction(t *testing.T) {
+	words := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+	wallet, err := NewHDWalletFromAccountExtendedPrivateKey(accountExtendedPrivateKeyForTest(t, words, yprv))
+	assert.Nil(t, err)
+
+	expected, err := words.BuildTransactionMetadata(bip49TransactionDataForTest(t))
+	assert.Nil(t, err)
+	actual, err := wallet.BuildTransactionMetadata(bip49TransactionDataForTest(t))
+	assert.Nil(t, err)
+	assert.Equal(t, expected.EncodedTx, actual.EncodedTx)
+	assert.Equal(t, expected.TransactionChangeMetadata.Address, actual.TransactionChangeMetadata.Address)
+}
+
+func TestNewHDWalletFromAccountExtendedPrivateKey_IdentityKeyUnavailable(t *testing.T) {
+	wallet, err := NewHDWalletFromAccountExtendedPrivateKey(bip84AccountZprv)
+	assert.Nil(t, err)
+
+	_, err = wallet.SigningPublicKey()
+	assert.Equal(t, ErrMasterKeyRequired, err)
+
+	_, err = wallet.SignData([]byte("hello"))
+	assert.Equal(t, ErrMasterKeyRequired, err)
+
+	_, err = wallet.IdentityPublicKeyForVersion(1)
+	assert.Equal(t, ErrMasterKeyRequired, err)
+}
+
+func TestNewHDWalletFromAccountExtendedPrivateKey_PathOutsideAccount(t *testing.T) {
+	wallet, err := NewHDWalletFromAccountExtendedPrivateKey(bip84AccountZprv)
+	assert.Nil(t, err)
+
+	_, err = wallet.CompressedPubKeyForPath(NewDerivationPath(NewBaseCoin(84, 0, 1), 0, 0))
+	assert.Equal(t, ErrPathOutsideAccount, err)
+
+	_, err = wallet.CompressedPubKeyForPath(NewDerivationPath(BaseCoinBip49MainNet, 0, 0))
+	assert.Equal(t, ErrPathOutsideAccount, err)
+
+	wallet.UpdateCoin(BaseCoinBip49MainNet)
+	_, err = wallet.ReceiveAddressForIndex(0)
+	assert.Equal(t, ErrPathOutsideAccount, err)
+}
+
+func TestNewHDWalletFromAccountExtendedPrivateKey_Rejects(t *testing.T) {
+	_, err := NewHDWalletFromAccountExtendedPrivateKey(abandonMasterXprv)
+	assert.Equal(t, ErrInvalidExtendedKey, err)
+
+	_, err = NewHDWalletFromAccountExtendedPrivateKey(bip84AccountZpub)
+	assert.Equal(t, ErrExpectedPrivateExtendedKey, err)
+}
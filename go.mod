@@ -1,18 +1,39 @@
 module git.coinninja.net/engineering/cnlib
 
-go 1.13
+go 1.20
 
 require (
 	github.com/FactomProject/basen v0.0.0-20150613233007-fe3947df716e // indirect
 	github.com/FactomProject/btcutilecc v0.0.0-20130527213604-d3a63a5752ec // indirect
 	github.com/btcsuite/btcd v0.20.1-beta
+	github.com/btcsuite/btcd/btcutil v1.0.0
 	github.com/btcsuite/btcutil v0.0.0-20191219182022-e17c9730c422
 	github.com/btcsuite/btcwallet/wallet/txauthor v1.0.0
 	github.com/lightningnetwork/lnd v0.8.2-beta
-	github.com/stretchr/testify v1.4.0
+	github.com/stretchr/testify v1.8.4
 	github.com/tyler-smith/go-bip32 v0.0.0-20170922074101-2c9cfd177564
 	github.com/tyler-smith/go-bip39 v1.0.2
 	github.com/worldiety/std v0.0.5
-	golang.org/x/crypto v0.0.0-20191227163750-53104e6ec876 // indirect
+	gitlab.com/yawning/secp256k1-voi v0.0.0-20230925100816-f2616030848b
+	golang.org/x/crypto v0.11.0
 	golang.org/x/mobile v0.0.0-20191031020345-0945064e013a // indirect
+	golang.org/x/text v0.11.0
+)
+
+require (
+	// lnd v0.8.2 pins an aez that imports bsaes from git.schwanenlied.me, which is no longer served; this aez imports it from gitlab
+	github.com/Yawning/aez v0.0.0-20211027044916-e49e68abd344 // indirect
+	github.com/btcsuite/btclog v0.0.0-20170628155309-84c8d2346e9f // indirect
+	github.com/btcsuite/btcwallet/wallet/txrules v1.0.0 // indirect
+	github.com/btcsuite/btcwallet/wallet/txsizes v1.0.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-errors/errors v1.0.1 // indirect
+	github.com/kkdai/bstream v0.0.0-20181106074824-b3251f7901ec // indirect
+	github.com/miekg/dns v0.0.0-20171125082028-79bfde677fa8 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gitlab.com/yawning/bsaes.git v0.0.0-20190805113838-0a714cd429ec // indirect
+	gitlab.com/yawning/tuplehash v0.0.0-20230713102510-df83abbf9a02 // indirect
+	golang.org/x/net v0.10.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -3,7 +3,9 @@ package cnlib
 import (
 	"encoding/hex"
 	"errors"
+	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -19,12 +21,19 @@ import (
 
 /// Type Declarations
 
-// HDWallet represents the user's current wallet.
+// HDWallet represents the user's current wallet. It is safe for concurrent use; the coin is read with BaseCoin and
+// switched with UpdateCoin, and each operation works on a snapshot of the coin taken when it starts.
 type HDWallet struct {
-	BaseCoin         *BaseCoin
-	WalletWords      string // space-separated string of user's recovery words
-	masterPrivateKey *hdkeychain.ExtendedKey
-	accountPublicKey *hdkeychain.ExtendedKey
+	baseCoin          *BaseCoin
+	WalletWords       string // space-separated string of user's recovery words
+	masterPrivateKey  *hdkeychain.ExtendedKey
+	accountPrivateKey *hdkeychain.ExtendedKey // optional, for wallets created from an account extended private key
+	accountCoin       *BaseCoin               // the account accountPrivateKey belongs to
+	accountPublicKey  *hdkeychain.ExtendedKey
+	accountKeyOrigin  *KeyOrigin // optional, for watch-only wallets
+	fingerprint       []byte     // master key fingerprint, for wallets with a master key
+	keyCache          *keyCache
+	mu                sync.RWMutex // guards baseCoin
 }
 
 // GetFullBIP39WordListString returns all 2,048 BIP39 mnemonic words as a space-separated string.
@@ -32,32 +41,60 @@ func GetFullBIP39WordListString() string {
 	return strings.Join(wordlists.English, " ")
 }
 
-// NewWordListFromEntropy returns a space-separated list of mnemonic words from entropy.
+// NewWordListFromEntropy returns a space-separated list of English mnemonic words from entropy.
 func NewWordListFromEntropy(entropy []byte) (string, error) {
-	words, err := bip39.NewMnemonic(entropy)
-	if err != nil {
-		return "", err
-	}
-	valid := bip39.IsMnemonicValid(words)
-	if !valid {
-		return "", errors.New("invalid mnemonic")
-	}
-	return words, nil
+	return NewWordListFromEntropyForLanguage(entropy, BIP39LanguageEnglish)
 }
 
 // NewHDWalletFromWords returns a pointer to an HDWallet, containing the BaseCoin, words, and unexported master private key.
 func NewHDWalletFromWords(wordString string, basecoin *BaseCoin) *HDWallet {
-	masterKey, err := masterPrivateKey(wordString, basecoin)
+	return NewHDWalletFromWordsWithPassphrase(wordString, "", basecoin)
+}
+
+// NewHDWalletFromWordsWithPassphrase returns a pointer to an HDWallet whose seed is derived from the words and a BIP39 passphrase.
+// Like NewHDWalletFromWords, the words are not validated. The passphrase is not stored on the wallet.
+func NewHDWalletFromWordsWithPassphrase(wordString string, passphrase string, basecoin *BaseCoin) *HDWallet {
+	masterKey, err := masterPrivateKeyWithPassphrase(wordString, passphrase, basecoin)
+	if err != nil {
+		return nil
+	}
+	wallet, err := newHDWalletFromMasterKey(masterKey, wordString, basecoin)
 	if err != nil {
 		return nil
 	}
+	return wallet
+}
+
+// NewHDWalletFromMnemonic returns a pointer to an HDWallet for a mnemonic in any supported wordlist language and an
+// optional BIP39 passphrase. Returns ErrInvalidMnemonic if the words or checksum are not valid in any language.
+func NewHDWalletFromMnemonic(wordString string, passphrase string, basecoin *BaseCoin) (*HDWallet, error) {
+	if basecoin == nil {
+		return nil, errors.New("no basecoin provided")
+	}
+	seed, err := mnemonicSeed(wordString, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := masterPrivateKeyFromSeed(seed, basecoin)
+	if err != nil {
+		return nil, err
+	}
+	return newHDWalletFromMasterKey(masterKey, wordString, basecoin)
+}
+
+// newHDWalletFromMasterKey returns a pointer to an HDWallet with full spending and identity capability for a master key.
+func newHDWalletFromMasterKey(masterKey *hdkeychain.ExtendedKey, wordString string, basecoin *BaseCoin) (*HDWallet, error) {
 	kf := keyFactory{masterPrivateKey: masterKey}
 	pubkey, _, err := kf.accountExtendedPublicKey(basecoin)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	wallet := HDWallet{BaseCoin: basecoin, WalletWords: wordString, masterPrivateKey: masterKey, accountPublicKey: pubkey}
-	return &wallet
+	fingerprint, err := extendedKeyFingerprint(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	wallet := HDWallet{baseCoin: basecoin.clone(), WalletWords: wordString, masterPrivateKey: masterKey, accountPublicKey: pubkey, fingerprint: fingerprint, keyCache: newKeyCache()}
+	return &wallet, nil
 }
 
 // NewHDWalletFromAccountExtendedPublicKey returns a pointer to an HDWallet, containing the BaseCoin, empty word list, nil master private key,
@@ -71,7 +108,7 @@ func NewHDWalletFromAccountExtendedPublicKey(acctPubKeyStr string) (*HDWallet, e
 	if err != nil {
 		return nil, err
 	}
-	wallet := HDWallet{BaseCoin: basecoin, WalletWords: "", masterPrivateKey: nil, accountPublicKey: key}
+	wallet := HDWallet{baseCoin: basecoin.clone(), WalletWords: "", masterPrivateKey: nil, accountPublicKey: key, keyCache: newKeyCache()}
 	return &wallet, nil
 }
 
@@ -88,14 +125,19 @@ func (wallet *HDWallet) SigningKey() ([]byte, error) {
 
 // SigningPublicKey returns the public key at the m/42 path.
 func (wallet *HDWallet) SigningPublicKey() ([]byte, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	return wallet.IdentityPublicKeyForVersion(legacyIdentityKeyVersion)
+}
 
-	smk, err := kf.signingMasterKey()
+// IdentityPublicKeyForVersion returns the compressed identity public key for a key version (0 is m/42, n > 0 is m/42'/n).
+func (wallet *HDWallet) IdentityPublicKeyForVersion(version int) ([]byte, error) {
+	kf := wallet.keyFactory()
+
+	key, err := kf.identityKey(version)
 	if err != nil {
 		return nil, err
 	}
 
-	ec, err := smk.ECPubKey()
+	ec, err := key.ECPubKey()
 	if err != nil {
 		return nil, err
 	}
@@ -112,26 +154,72 @@ func (wallet *HDWallet) CoinNinjaVerificationKeyHexString() (string, error) {
 	return hex.EncodeToString(key), nil
 }
 
+// CoinNinjaVerificationKeyHexStringForVersion returns the hex-encoded identity public key for a key version.
+func (wallet *HDWallet) CoinNinjaVerificationKeyHexStringForVersion(version int) (string, error) {
+	key, err := wallet.IdentityPublicKeyForVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
 // ReceiveAddressForIndex returns a receive MetaAddress derived from the current wallet, BaseCoin, and index.
+// BIP86 wallets return ErrTaprootSpendingUnsupported.
 func (wallet *HDWallet) ReceiveAddressForIndex(index int) (*MetaAddress, error) {
-	if wallet.masterPrivateKey != nil {
-		return wallet.metaAddress(0, index)
-	} else if wallet.accountPublicKey != nil {
-		return indexMetaAddressFromExtendedPubkey(wallet.accountPublicKey, wallet.BaseCoin, 0, uint32(index))
+	basecoin := wallet.coin()
+	if basecoin.Purpose == bip86purpose {
+		return nil, ErrTaprootSpendingUnsupported
 	}
-
-	return nil, errors.New("no valid master private key or account extended public key found")
+	return wallet.addressForIndex(basecoin, 0, index)
 }
 
 // ChangeAddressForIndex returns a change MetaAddress derived from the current wallet, BaseCoin, and index.
+// BIP86 wallets return ErrTaprootSpendingUnsupported.
 func (wallet *HDWallet) ChangeAddressForIndex(index int) (*MetaAddress, error) {
-	if wallet.masterPrivateKey != nil {
-		return wallet.metaAddress(1, index)
+	basecoin := wallet.coin()
+	if basecoin.Purpose == bip86purpose {
+		return nil, ErrTaprootSpendingUnsupported
+	}
+	return wallet.addressForIndex(basecoin, 1, index)
+}
+
+// MessageSigningAddressForIndex returns a MetaAddress for use with SignMessage, on the receive (0) or change (1) chain.
+// Unlike ReceiveAddressForIndex, BIP86 addresses are returned, and must not be handed out to receive funds.
+func (wallet *HDWallet) MessageSigningAddressForIndex(change int, index int) (*MetaAddress, error) {
+	return wallet.addressForIndex(wallet.coin(), change, index)
+}
+
+// addressForIndex derives a receive or change MetaAddress for a BaseCoin snapshot.
+func (wallet *HDWallet) addressForIndex(basecoin *BaseCoin, change int, index int) (*MetaAddress, error) {
+	var ma *MetaAddress
+	var err error
+	if wallet.masterPrivateKey != nil || wallet.accountPrivateKey != nil {
+		ma, err = wallet.metaAddress(basecoin, change, index)
 	} else if wallet.accountPublicKey != nil {
-		return indexMetaAddressFromExtendedPubkey(wallet.accountPublicKey, wallet.BaseCoin, 1, uint32(index))
+		ma, err = wallet.watchOnlyMetaAddress(basecoin, change, index)
+	} else {
+		return nil, errors.New("no valid master private key or account extended public key found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if origin, err := wallet.accountKeyOriginForCoin(basecoin); err == nil {
+		ma.KeyOrigin = origin.child(uint32(change), uint32(index))
 	}
+	return ma, nil
+}
 
-	return nil, errors.New("no valid master private key or account extended public key found")
+// watchOnlyMetaAddress derives a MetaAddress from the account extended public key, reusing the cached chain key.
+func (wallet *HDWallet) watchOnlyMetaAddress(basecoin *BaseCoin, change int, index int) (*MetaAddress, error) {
+	if wallet.keyCache == nil {
+		return indexMetaAddressFromExtendedPubkey(wallet.accountPublicKey, basecoin, uint32(change), uint32(index))
+	}
+	changeKey, err := wallet.keyCache.publicChainKey(wallet.accountPublicKey, basecoin, uint32(change))
+	if err != nil {
+		return nil, err
+	}
+	return chainMetaAddressFromExtendedPubkey(changeKey, basecoin, uint32(change), uint32(index))
 }
 
 // indexMetaAddressFromExtendedPubkey is a private method to use shared code to create internal/external (change) MetaAddresses with a given index.
@@ -140,6 +228,11 @@ func indexMetaAddressFromExtendedPubkey(extPubkey *hdkeychain.ExtendedKey, basec
 	if err != nil {
 		return nil, err
 	}
+	return chainMetaAddressFromExtendedPubkey(changeKey, basecoin, change, index)
+}
+
+// chainMetaAddressFromExtendedPubkey creates a MetaAddress from a change chain extended public key.
+func chainMetaAddressFromExtendedPubkey(changeKey *hdkeychain.ExtendedKey, basecoin *BaseCoin, change uint32, index uint32) (*MetaAddress, error) {
 	indexKey, err := changeKey.Child(index)
 	if err != nil {
 		return nil, err
@@ -158,19 +251,49 @@ func indexMetaAddressFromExtendedPubkey(extPubkey *hdkeychain.ExtendedKey, basec
 	return meta, nil
 }
 
-// UpdateCoin updates the pointer stored to a new instance of BaseCoin. Fetched MetaAddresses will reflect updated coin.
+// ReceiveAddressesForRange returns count receive MetaAddresses starting at index start.
+// BIP86 wallets return ErrTaprootSpendingUnsupported.
+func (wallet *HDWallet) ReceiveAddressesForRange(start int, count int) (*MetaAddressList, error) {
+	basecoin := wallet.coin()
+	if basecoin.Purpose == bip86purpose {
+		return nil, ErrTaprootSpendingUnsupported
+	}
+	return wallet.addressesForRange(basecoin, 0, start, count)
+}
+
+// ChangeAddressesForRange returns count change MetaAddresses starting at index start.
+// BIP86 wallets return ErrTaprootSpendingUnsupported.
+func (wallet *HDWallet) ChangeAddressesForRange(start int, count int) (*MetaAddressList, error) {
+	basecoin := wallet.coin()
+	if basecoin.Purpose == bip86purpose {
+		return nil, ErrTaprootSpendingUnsupported
+	}
+	return wallet.addressesForRange(basecoin, 1, start, count)
+}
+
+// BaseCoin returns a copy of the wallet's current BaseCoin.
+func (wallet *HDWallet) BaseCoin() *BaseCoin {
+	return wallet.coin()
+}
+
+// UpdateCoin stores a copy of the given BaseCoin. Fetched MetaAddresses will reflect updated coin; operations already
+// in progress finish with the coin they started with.
 func (wallet *HDWallet) UpdateCoin(c *BaseCoin) {
-	wallet.BaseCoin = c
+	wallet.mu.Lock()
+	defer wallet.mu.Unlock()
+	wallet.baseCoin = c.clone()
+	wallet.keyCache.clear()
 }
 
 // CheckForAddress scans the wallet for a given address up to a given index on both receive/change chains.
 func (wallet *HDWallet) CheckForAddress(a string, upTo int) (*MetaAddress, error) {
+	basecoin := wallet.coin()
 	for i := 0; i < upTo; i++ {
-		rma, err := wallet.ReceiveAddressForIndex(i)
+		rma, err := wallet.addressForIndex(basecoin, 0, i)
 		if err != nil {
 			return nil, err
 		}
-		cma, err := wallet.ChangeAddressForIndex(i)
+		cma, err := wallet.addressForIndex(basecoin, 1, i)
 		if err != nil {
 			return nil, err
 		}
@@ -186,16 +309,67 @@ func (wallet *HDWallet) CheckForAddress(a string, upTo int) (*MetaAddress, error
 
 // SignData signs a given message and returns the signature in bytes.
 func (wallet *HDWallet) SignData(message []byte) ([]byte, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	kf := wallet.keyFactory()
 	return kf.signData(message)
 }
 
 // SignatureSigningData signs a given message and returns the signature in hex-encoded string format.
 func (wallet *HDWallet) SignatureSigningData(message []byte) (string, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	kf := wallet.keyFactory()
 	return kf.signatureSigningData(message)
 }
 
+// SignDataForVersion signs a given message with the identity key for a key version and returns the signature in bytes.
+func (wallet *HDWallet) SignDataForVersion(version int, message []byte) ([]byte, error) {
+	kf := wallet.keyFactory()
+	return kf.signDataForVersion(version, message)
+}
+
+// SignatureSigningDataForVersion signs a given message with the identity key for a key version and returns the signature in hex-encoded string format.
+func (wallet *HDWallet) SignatureSigningDataForVersion(version int, message []byte) (string, error) {
+	kf := wallet.keyFactory()
+	return kf.signatureSigningDataForVersion(version, message)
+}
+
+// SignKeyRotation returns a KeyRotationStatement moving from oldVersion to newVersion, signed by both identity keys.
+func (wallet *HDWallet) SignKeyRotation(oldVersion int, newVersion int, timestamp int64) (*KeyRotationStatement, error) {
+	if oldVersion == newVersion {
+		return nil, ErrInvalidKeyRotation
+	}
+
+	oldPubkey, err := wallet.CoinNinjaVerificationKeyHexStringForVersion(oldVersion)
+	if err != nil {
+		return nil, err
+	}
+	newPubkey, err := wallet.CoinNinjaVerificationKeyHexStringForVersion(newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := NewKeyRotationStatement(oldVersion, oldPubkey, newVersion, newPubkey, timestamp)
+	message := []byte(statement.CanonicalString())
+
+	statement.OldKeySignature, err = wallet.SignatureSigningDataForVersion(oldVersion, message)
+	if err != nil {
+		return nil, err
+	}
+	statement.NewKeySignature, err = wallet.SignatureSigningDataForVersion(newVersion, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+// SignRequest signs the canonical string of a request with the m/42 key and returns the hex-encoded DER signature.
+func (wallet *HDWallet) SignRequest(request *RequestSigningData) (string, error) {
+	canonical, err := request.CanonicalString()
+	if err != nil {
+		return "", err
+	}
+	return wallet.SignatureSigningData([]byte(canonical))
+}
+
 // EncryptWithEphemeralKey encrypts a given body (byte slice) using ECDH symmetric key encryption by creating an ephemeral keypair from entropy and given uncompressed public key.
 func (wallet *HDWallet) EncryptWithEphemeralKey(entropy []byte, body []byte, recipientUncompressedPubkey string) ([]byte, error) {
 	pubkeyBytes, err := hex.DecodeString(recipientUncompressedPubkey)
@@ -213,7 +387,7 @@ func (wallet *HDWallet) EncryptWithEphemeralKey(entropy []byte, body []byte, rec
 		return nil, err
 	}
 
-	w := NewHDWalletFromWords(m, wallet.BaseCoin)
+	w := NewHDWalletFromWords(m, wallet.coin())
 	privateKey, err := w.masterPrivateKey.ECPrivKey()
 	if err != nil {
 		return nil, err
@@ -224,7 +398,7 @@ func (wallet *HDWallet) EncryptWithEphemeralKey(entropy []byte, body []byte, rec
 
 // DecryptWithKeyFromDerivationPath decrypts a given payload with the key derived from given derivation path.
 func (wallet *HDWallet) DecryptWithKeyFromDerivationPath(path *DerivationPath, body []byte) ([]byte, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	kf := wallet.keyFactory()
 
 	pk, err := kf.indexPrivateKey(path)
 	if err != nil {
@@ -269,27 +443,269 @@ func (wallet *HDWallet) DecryptMessage(body []byte) ([]byte, error) {
 	return decrypt(body, signingKey)
 }
 
+// EncryptMessageForVersion encrypts a payload using the identity key for a key version and recipient's public key.
+func (wallet *HDWallet) EncryptMessageForVersion(version int, body []byte, recipientUncompressedPubkey string) ([]byte, error) {
+	pubkeyBytes, err := hex.DecodeString(recipientUncompressedPubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := btcec.ParsePubKey(pubkeyBytes, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	identityKey, err := wallet.identityPrivateKey(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt(body, identityKey, publicKey)
+}
+
+// DecryptMessageForVersion decrypts a payload using the identity key for a key version, and returns the plain text along with the sender public key.
+func (wallet *HDWallet) DecryptMessageForVersion(version int, body []byte) (*DecryptedMessage, error) {
+	identityKey, err := wallet.identityPrivateKey(version)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, sender, err := decryptWithSender(body, identityKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDecryptedMessage(decrypted, sender), nil
+}
+
+// DecryptMessageWithSender decrypts a payload using signing key (m/42), and returns the plain text along with the sender public key included in the payload.
+func (wallet *HDWallet) DecryptMessageWithSender(body []byte) (*DecryptedMessage, error) {
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, sender, err := decryptWithSender(body, signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDecryptedMessage(decrypted, sender), nil
+}
+
+// DecryptMessageFromSender decrypts a payload using signing key (m/42), and returns an error if the payload was not sent by
+// the expected sender. The expected public key may be hex-encoded compressed (i.e. a CoinNinjaVerificationKeyHexString) or uncompressed.
+func (wallet *HDWallet) DecryptMessageFromSender(body []byte, expectedSenderPubkey string) (*DecryptedMessage, error) {
+	pubkeyBytes, err := hex.DecodeString(expectedSenderPubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := btcec.ParsePubKey(pubkeyBytes, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, sender, err := decryptWithSender(body, signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sender.IsEqual(expected) {
+		return nil, ErrUnexpectedSender
+	}
+
+	return newDecryptedMessage(decrypted, sender), nil
+}
+
+// EncryptMessageForRecipients encrypts a payload once using signing key (m/42), wrapping the content key for each of the given
+// recipients (space-separated, hex-encoded public keys). Include your own verification key to be able to read the payload later.
+func (wallet *HDWallet) EncryptMessageForRecipients(body []byte, recipientPubkeys string) ([]byte, error) {
+	recipients, err := parseRecipientPublicKeys(recipientPubkeys)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptEnvelope(body, signingKey, recipients)
+}
+
+// DecryptEnvelope decrypts a multi-recipient payload using signing key (m/42), and returns the plain text along with the sender public key.
+func (wallet *HDWallet) DecryptEnvelope(body []byte) (*DecryptedMessage, error) {
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, sender, err := decryptEnvelope(body, signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDecryptedMessage(decrypted, sender), nil
+}
+
+// DecryptEnvelopeWithKeyFromDerivationPath decrypts a multi-recipient payload with the key derived from given derivation path.
+func (wallet *HDWallet) DecryptEnvelopeWithKeyFromDerivationPath(path *DerivationPath, body []byte) (*DecryptedMessage, error) {
+	kf := wallet.keyFactory()
+
+	pk, err := kf.indexPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ecpk, err := pk.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, sender, err := decryptEnvelope(body, ecpk)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDecryptedMessage(decrypted, sender), nil
+}
+
+// EncryptStream encrypts everything read from src to the recipient's public key using signing key (m/42), writing
+// authenticated chunks to dst so that large payloads can be processed in constant memory.
+func (wallet *HDWallet) EncryptStream(dst io.Writer, src io.Reader, recipientUncompressedPubkey string) error {
+	pubkeyBytes, err := hex.DecodeString(recipientUncompressedPubkey)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := btcec.ParsePubKey(pubkeyBytes, btcec.S256())
+	if err != nil {
+		return err
+	}
+
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return err
+	}
+
+	return encryptStream(dst, src, streamDefaultChunkSize, signingKey, publicKey)
+}
+
+// DecryptStream decrypts a stream using signing key (m/42), writing plain text to dst, and returns the hex-encoded compressed
+// public key of the sender. If an error is returned, anything already written to dst must be discarded.
+func (wallet *HDWallet) DecryptStream(dst io.Writer, src io.Reader) (string, error) {
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	sender, err := decryptStream(dst, src, signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(sender.SerializeCompressed()), nil
+}
+
+// DecryptStreamWithKeyFromDerivationPath decrypts a stream with the key derived from given derivation path, writing plain text to dst,
+// and returns the hex-encoded compressed public key of the sender. If an error is returned, anything already written to dst must be discarded.
+func (wallet *HDWallet) DecryptStreamWithKeyFromDerivationPath(path *DerivationPath, dst io.Writer, src io.Reader) (string, error) {
+	kf := wallet.keyFactory()
+
+	pk, err := kf.indexPrivateKey(path)
+	if err != nil {
+		return "", err
+	}
+
+	ecpk, err := pk.ECPrivKey()
+	if err != nil {
+		return "", err
+	}
+
+	sender, err := decryptStream(dst, src, ecpk)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(sender.SerializeCompressed()), nil
+}
+
+// EncryptMessageBIE1 encrypts a payload to the recipient's hex-encoded public key in the Electrum-compatible "BIE1" ECIES format,
+// using a fresh ephemeral key, and returns the base64 encoded result.
+func (wallet *HDWallet) EncryptMessageBIE1(body []byte, recipientPubkey string) (string, error) {
+	pubkeyBytes, err := hex.DecodeString(recipientPubkey)
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, err := btcec.ParsePubKey(pubkeyBytes, btcec.S256())
+	if err != nil {
+		return "", err
+	}
+
+	return encryptBIE1(body, publicKey)
+}
+
+// DecryptMessageBIE1 decrypts a base64 encoded "BIE1" payload using signing key (m/42).
+func (wallet *HDWallet) DecryptMessageBIE1(payload string) ([]byte, error) {
+	signingKey, err := wallet.signingPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptBIE1(payload, signingKey)
+}
+
+// DecryptBIE1WithKeyFromDerivationPath decrypts a base64 encoded "BIE1" payload with the key derived from given derivation path.
+func (wallet *HDWallet) DecryptBIE1WithKeyFromDerivationPath(path *DerivationPath, payload string) ([]byte, error) {
+	kf := wallet.keyFactory()
+
+	pk, err := kf.indexPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ecpk, err := pk.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptBIE1(payload, ecpk)
+}
+
 // ImportPrivateKey accepts an encoded private key from a paper wallet/QR code, decodes it, and returns a ref to an ImportedPrivateKey struct, or error if failed.
 func (wallet *HDWallet) ImportPrivateKey(encodedKey string) (*ImportedPrivateKey, error) {
 	wif, err := btcutil.DecodeWIF(encodedKey)
 	if err != nil {
 		return nil, err
 	}
+	return wallet.importedPrivateKeyFromWIF(wif)
+}
 
+// importedPrivateKeyFromWIF builds an ImportedPrivateKey with the legacy, legacy segwit and native segwit addresses for a key.
+func (wallet *HDWallet) importedPrivateKeyFromWIF(wif *btcutil.WIF) (*ImportedPrivateKey, error) {
 	serializedPubkey := wif.SerializePubKey()
 	hash160 := btcutil.Hash160(serializedPubkey)
 
 	// legacy
 	legacy := base58.CheckEncode(hash160, 0)
 
+	basecoin := wallet.coin()
+
 	// legacy segwit
-	ls, err := bip49AddressFromPubkeyHash(hash160, wallet.BaseCoin)
+	ls, err := bip49AddressFromPubkeyHash(hash160, basecoin)
 	if err != nil {
 		return nil, err
 	}
 
 	// native segwit
-	ns, err := bip84AddressFromPubkeyHash(hash160, wallet.BaseCoin)
+	ns, err := bip84AddressFromPubkeyHash(hash160, basecoin)
 	if err != nil {
 		return nil, err
 	}
@@ -303,8 +719,8 @@ func (wallet *HDWallet) ImportPrivateKey(encodedKey string) (*ImportedPrivateKey
 
 // AccountExtendedMasterPublicKey returns the stringified base58 encoded master extended public key.
 func (wallet *HDWallet) AccountExtendedMasterPublicKey() (string, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
-	_, pubkeyString, err := kf.accountExtendedPublicKey(wallet.BaseCoin)
+	kf := wallet.keyFactory()
+	_, pubkeyString, err := kf.accountExtendedPublicKey(wallet.coin())
 	if err != nil {
 		return "", err
 	}
@@ -313,13 +729,13 @@ func (wallet *HDWallet) AccountExtendedMasterPublicKey() (string, error) {
 
 // BuildTransactionMetadata will generate the tx metadata needed for client to consume.
 func (wallet *HDWallet) BuildTransactionMetadata(data *TransactionData) (*TransactionMetadata, error) {
-	builder := transactionBuilder{wallet: wallet}
+	builder := transactionBuilder{wallet: wallet, basecoin: wallet.coin()}
 	return builder.buildTxFromData(data)
 }
 
 // DecodeLightningInvoice returns a reference to an invoice.Invoice object if valid, or error if invalid.
 func (wallet *HDWallet) DecodeLightningInvoice(invoice string) (*LightningInvoice, error) {
-	inv, err := zpay32.Decode(invoice, wallet.BaseCoin.defaultNetParams())
+	inv, err := zpay32.Decode(invoice, wallet.coin().defaultNetParams())
 	if err != nil {
 		return nil, err
 	}
@@ -373,8 +789,8 @@ func (wallet *HDWallet) publicKey(path *DerivationPath) (*btcec.PublicKey, error
 		return nil, errors.New("derivation path cannot be nil")
 	}
 
-	keyFactory := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
-	privKey, err := keyFactory.indexPrivateKey(path)
+	kf := wallet.keyFactory()
+	privKey, err := kf.indexPrivateKey(path)
 	if err != nil {
 		return nil, err
 	}
@@ -385,7 +801,7 @@ func (wallet *HDWallet) publicKey(path *DerivationPath) (*btcec.PublicKey, error
 	return pubKey, nil
 }
 
-func (wallet *HDWallet) metaAddress(change int, index int) (*MetaAddress, error) {
+func (wallet *HDWallet) metaAddress(basecoin *BaseCoin, change int, index int) (*MetaAddress, error) {
 	if change < 0 {
 		return nil, errors.New("change index cannot be negative")
 	}
@@ -394,7 +810,7 @@ func (wallet *HDWallet) metaAddress(change int, index int) (*MetaAddress, error)
 		return nil, errors.New("index cannot be negative")
 	}
 
-	path := NewDerivationPath(wallet.BaseCoin, change, index)
+	path := NewDerivationPath(basecoin, change, index)
 
 	ua, err := newUsableAddressWithDerivationPath(wallet, path)
 	if err != nil {
@@ -409,27 +825,73 @@ func hardened(i int) uint32 {
 }
 
 func masterPrivateKey(wordString string, basecoin *BaseCoin) (*hdkeychain.ExtendedKey, error) {
-	seed := bip39.NewSeed(wordString, "")
+	return masterPrivateKeyWithPassphrase(wordString, "", basecoin)
+}
+
+func masterPrivateKeyWithPassphrase(wordString string, passphrase string, basecoin *BaseCoin) (*hdkeychain.ExtendedKey, error) {
+	return masterPrivateKeyFromSeed(bip39Seed(wordString, passphrase), basecoin)
+}
+
+func masterPrivateKeyFromSeed(seed []byte, basecoin *BaseCoin) (*hdkeychain.ExtendedKey, error) {
 	defaultNet := basecoin.defaultNetParams()
 	masterKey, err := hdkeychain.NewMaster(seed, defaultNet)
 	if err != nil {
 		return nil, err
 	}
+	primePublicKey(masterKey)
 	return masterKey, nil
 }
 
 func (wallet *HDWallet) signingPrivateKey() (*btcec.PrivateKey, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	return wallet.identityPrivateKey(legacyIdentityKeyVersion)
+}
+
+func (wallet *HDWallet) identityPrivateKey(version int) (*btcec.PrivateKey, error) {
+	kf := wallet.keyFactory()
 
-	smk, err := kf.signingMasterKey()
+	key, err := kf.identityKey(version)
 	if err != nil {
 		return nil, err
 	}
 
-	ec, err := smk.ECPrivKey()
+	ec, err := key.ECPrivKey()
 	if err != nil {
 		return nil, err
 	}
 
 	return ec, nil
 }
+
+func (wallet *HDWallet) keyFactory() keyFactory {
+	return keyFactory{
+		masterPrivateKey: wallet.masterPrivateKey,
+		acctExtPrivKey:   wallet.accountPrivateKey,
+		acctCoin:         wallet.accountCoin,
+		acctExtPubKey:    wallet.accountPublicKey,
+		cache:            wallet.keyCache,
+	}
+}
+
+func (wallet *HDWallet) addressesForRange(basecoin *BaseCoin, change int, start int, count int) (*MetaAddressList, error) {
+	if start < 0 || count < 0 {
+		return nil, errors.New("range cannot be negative")
+	}
+
+	addresses := make([]*MetaAddress, count)
+	for i := range addresses {
+		ma, err := wallet.addressForIndex(basecoin, change, start+i)
+		if err != nil {
+			return nil, err
+		}
+		addresses[i] = ma
+	}
+
+	return &MetaAddressList{addresses: addresses}, nil
+}
+
+// coin returns a snapshot of the wallet's BaseCoin, so an operation is unaffected by a concurrent UpdateCoin.
+func (wallet *HDWallet) coin() *BaseCoin {
+	wallet.mu.RLock()
+	defer wallet.mu.RUnlock()
+	return wallet.baseCoin.clone()
+}
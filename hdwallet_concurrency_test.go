@@ -0,0 +1,129 @@
+package cnlib
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// These tests are most useful under the race detector: go test -race ./...
+
+func TestHDWallet_StoresCopyOfBaseCoin(t *testing.T) {
+	basecoin := NewBaseCoin(84, 0, 0)
+	wallet := NewHDWalletFromWords(w, basecoin)
+
+	basecoin.UpdatePurpose(49)
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 84, ma.DerivationPath.Purpose)
+
+	wallet.UpdateCoin(basecoin)
+	basecoin.UpdatePurpose(84)
+	ma, err = wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 49, ma.DerivationPath.Purpose)
+	assert.Equal(t, "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf", ma.Address)
+
+	// BaseCoin hands out a copy as well
+	wallet.BaseCoin().UpdatePurpose(84)
+	assert.Equal(t, 49, wallet.BaseCoin().Purpose)
+}
+
+func TestHDWallet_ConcurrentDerivationAndCoinSwitch(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	expected := map[int]string{}
+	for _, purpose := range []int{49, 84} {
+		ma, err := NewHDWalletFromWords(w, NewBaseCoin(purpose, 0, 0)).ChangeAddressForIndex(3)
+		assert.Nil(t, err)
+		expected[purpose] = ma.Address
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			wallet.UpdateCoin(NewBaseCoin(49+35*(i%2), 0, 0))
+		}
+	}()
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				ma, err := wallet.ChangeAddressForIndex(3)
+				assert.Nil(t, err)
+				// the address must always match the purpose it reports
+				assert.Equal(t, expected[ma.DerivationPath.Purpose], ma.Address)
+
+				_, err = wallet.AccountExtendedMasterPublicKey()
+				assert.Nil(t, err)
+				_, err = wallet.CheckForAddress(expected[84], 4)
+				assert.True(t, err == nil || err.Error() == "address not found")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHDWallet_ConcurrentBuildTransactionMetadata(t *testing.T) {
+	newData := func() *TransactionData {
+		path := NewDerivationPath(BaseCoinBip49MainNet, 0, 0)
+		utxo := NewUTXO("3480e31ea00efeb570472983ff914694f62804e768a6c6b4d1b6cd70a1cd3efa", 1, 449893, path, nil, true)
+		changePath := NewDerivationPath(BaseCoinBip49MainNet, 1, 0)
+		data := NewTransactionDataFlatFee("3ERQiyXSeUYmxxqKyg8XwqGo4W7utgDrTR", BaseCoinBip49MainNet, 218384, 668, changePath, 500000)
+		data.AddUTXO(utxo)
+		assert.Nil(t, data.Generate())
+		return data.TransactionData
+	}
+	expectedTxid := "221ced4e8784290dea336afa1b0a06fa868812e51abbdca3126ce8d99335a6e2"
+
+	wallet := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				meta, err := wallet.BuildTransactionMetadata(newData())
+				assert.Nil(t, err)
+				assert.Equal(t, expectedTxid, meta.Txid)
+				assert.Equal(t, "34K56kSjgUCUSD8GTtuF7c9Zzwokbs6uZ7", meta.TransactionChangeMetadata.Address)
+			}
+		}()
+	}
+
+	// address derivation on other goroutines shares the key cache with the builder
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				_, err := wallet.ReceiveAddressForIndex(g*10 + i)
+				assert.Nil(t, err)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestHDWallet_BuildTransactionMetadataUsesCoinSnapshot(t *testing.T) {
+	path := NewDerivationPath(BaseCoinBip49MainNet, 0, 0)
+	utxo := NewUTXO("3480e31ea00efeb570472983ff914694f62804e768a6c6b4d1b6cd70a1cd3efa", 1, 449893, path, nil, true)
+	changePath := NewDerivationPath(BaseCoinBip49MainNet, 1, 0)
+	data := NewTransactionDataFlatFee("3ERQiyXSeUYmxxqKyg8XwqGo4W7utgDrTR", BaseCoinBip49MainNet, 218384, 668, changePath, 500000)
+	data.AddUTXO(utxo)
+	assert.Nil(t, data.Generate())
+
+	wallet := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+	builder := transactionBuilder{wallet: wallet, basecoin: wallet.coin()}
+
+	// a switch after the build started must not affect it
+	wallet.UpdateCoin(BaseCoinBip84MainNet)
+	meta, err := builder.buildTxFromData(data.TransactionData)
+	assert.Nil(t, err)
+	assert.Equal(t, "34K56kSjgUCUSD8GTtuF7c9Zzwokbs6uZ7", meta.TransactionChangeMetadata.Address)
+}
@@ -13,13 +13,53 @@ import (
 	"github.com/btcsuite/btcd/btcec"
 )
 
-const minPayloadSize = 131
+const (
+	minPayloadSize = 131
+	payloadVersion = byte(3)
+)
+
+var (
+	// ErrInsufficientData describes an error in which the payload is too short
+	// to contain a version, options, iv, one cipher block, hmac and public key.
+	ErrInsufficientData = errors.New("insufficient data")
+
+	// ErrInvalidPayloadVersion describes an error in which the payload's version
+	// byte is not one this library knows how to decrypt.
+	ErrInvalidPayloadVersion = errors.New("invalid payload version")
+
+	// ErrInvalidPayloadOption describes an error in which the payload's options
+	// byte requests a feature this library does not support.
+	ErrInvalidPayloadOption = errors.New("invalid payload option")
+
+	// ErrInvalidCipherTextLength describes an error in which the cipher text is
+	// not a whole number of AES blocks.
+	ErrInvalidCipherTextLength = errors.New("invalid cipher text length")
+
+	// ErrInvalidHMAC describes an error in which the payload fails authentication.
+	ErrInvalidHMAC = errors.New("invalid hmac")
+
+	// ErrInvalidPadding describes an error in which the decrypted payload does
+	// not end in valid PKCS#7 padding.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrUnexpectedSender describes an error in which a payload decrypted
+	// successfully, but was sent by a key other than the one expected.
+	ErrUnexpectedSender = errors.New("unexpected sender public key")
+)
 
 // decrypt data using public/private keypair
 func decrypt(data []byte, privateKey *btcec.PrivateKey) ([]byte, error) {
+	decrypted, _, err := decryptWithSender(data, privateKey)
+	return decrypted, err
+}
+
+// decryptWithSender decrypts data using public/private keypair, and returns the sender public key embedded in the payload.
+// Because the hmac key is derived from the ECDH secret between sender and recipient, a payload that authenticates
+// can only have been produced by the holder of the embedded sender key (or by the recipient).
+func decryptWithSender(data []byte, privateKey *btcec.PrivateKey) ([]byte, *btcec.PublicKey, error) {
 
 	if len(data) < minPayloadSize {
-		return nil, errors.New("insufficient data")
+		return nil, nil, ErrInsufficientData
 	}
 
 	version := data[:1]
@@ -29,8 +69,16 @@ func decrypt(data []byte, privateKey *btcec.PrivateKey) ([]byte, error) {
 	hmacVal := data[len(data)-32-65 : len(data)-65]
 	publicKeyUncomp := data[len(data)-65:]
 
+	if version[0] != payloadVersion {
+		return nil, nil, ErrInvalidPayloadVersion
+	}
+
 	if options[0] != byte(0) {
-		return nil, errors.New("invalid payload option")
+		return nil, nil, ErrInvalidPayloadOption
+	}
+
+	if len(cipherText) == 0 || len(cipherText)%aes.BlockSize != 0 {
+		return nil, nil, ErrInvalidCipherTextLength
 	}
 
 	msg := make([]byte, 0)
@@ -41,7 +89,7 @@ func decrypt(data []byte, privateKey *btcec.PrivateKey) ([]byte, error) {
 
 	publicKey, err := btcec.ParsePubKey(publicKeyUncomp, btcec.S256())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	secret := generateSharedSecretRFC4753(privateKey, publicKey)
@@ -53,19 +101,19 @@ func decrypt(data []byte, privateKey *btcec.PrivateKey) ([]byte, error) {
 	testHmac := hmac.New(sha256.New, hmacKey)
 	_, err = testHmac.Write(msg)
 	if err != nil {
-		return nil, errors.New("failed to write testHmac")
+		return nil, nil, errors.New("failed to write testHmac")
 	}
 	testHmacVal := testHmac.Sum(nil)
 
 	// its important to use hmac.Equal to not leak time
 	// information. See https://github.com/RNCryptor/RNCryptor-Spec
 	if verified := hmac.Equal(testHmacVal, hmacVal); !verified {
-		return nil, errors.New("invalid hmac")
+		return nil, nil, ErrInvalidHMAC
 	}
 
 	cipherBlock, err := aes.NewCipher(encKey)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	decrypted := make([]byte, len(cipherText))
@@ -73,11 +121,33 @@ func decrypt(data []byte, privateKey *btcec.PrivateKey) ([]byte, error) {
 	decrypter := cipher.NewCBCDecrypter(cipherBlock, iv)
 	decrypter.CryptBlocks(decrypted, decrypted)
 
-	// un-padd decrypted data
-	length := len(decrypted)
-	unpadding := int(decrypted[length-1])
+	unpadded, err := unpad(decrypted, cipherBlock.BlockSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return unpadded, publicKey, nil
+}
+
+// unpad strips and validates PKCS#7 padding from decrypted data.
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	length := len(data)
+	if length == 0 || length%blockSize != 0 {
+		return nil, ErrInvalidPadding
+	}
+
+	padding := int(data[length-1])
+	if padding == 0 || padding > blockSize {
+		return nil, ErrInvalidPadding
+	}
+
+	for _, b := range data[length-padding:] {
+		if int(b) != padding {
+			return nil, ErrInvalidPadding
+		}
+	}
 
-	return decrypted[:(length - unpadding)], nil
+	return data[:(length - padding)], nil
 }
 
 // encrypt Data using public/private keypair
@@ -96,7 +166,7 @@ func encrypt(data []byte, privateKey *btcec.PrivateKey, publicKey *btcec.PublicK
 	cipherText := make([]byte, len(data))
 	copy(cipherText, data)
 
-	version := byte(3)
+	version := payloadVersion
 	options := byte(0) // No Password, No HMAC Salt, No Enryption Salt
 
 	msg := make([]byte, 0)
@@ -0,0 +1,62 @@
+//go:build go1.18
+// +build go1.18
+
+package cnlib
+
+import (
+	"testing"
+)
+
+func fuzzSeedPayloads(f *testing.F, sender *HDWallet, recipient *HDWallet) {
+	signingKey, err := recipient.CoinNinjaVerificationKeyHexString()
+	if err != nil {
+		f.Fatal(err)
+	}
+	receive, err := recipient.ReceiveAddressForIndex(0)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, body := range []string{"", "hey dude", "a message that spans more than a single aes block"} {
+		enc, err := sender.EncryptMessage([]byte(body), signingKey)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(enc)
+
+		enc, err = sender.EncryptWithEphemeralKey(make([]byte, 16), []byte(body), receive.UncompressedPublicKey)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(enc)
+	}
+	f.Add([]byte{})
+	f.Add(make([]byte, minPayloadSize))
+}
+
+func FuzzDecryptMessage(f *testing.F) {
+	alice := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bob := NewHDWalletFromWords("zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong", BaseCoinBip84MainNet)
+	fuzzSeedPayloads(f, alice, bob)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		dec, err := bob.DecryptMessage(payload)
+		if err != nil && dec != nil {
+			t.Fatalf("expected nil plain text alongside error %v", err)
+		}
+	})
+}
+
+func FuzzDecryptWithKeyFromDerivationPath(f *testing.F) {
+	alice := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bob := NewHDWalletFromWords("zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong", BaseCoinBip84MainNet)
+	fuzzSeedPayloads(f, alice, bob)
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 0)
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		dec, err := bob.DecryptWithKeyFromDerivationPath(path, payload)
+		if err != nil && dec != nil {
+			t.Fatalf("expected nil plain text alongside error %v", err)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package cnlib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+// sealRawPayload builds a v3 payload around already-padded plain text, so tests can produce authentic payloads with broken padding.
+func sealRawPayload(t *testing.T, plainText []byte, sender *btcec.PrivateKey, recipient *btcec.PublicKey) []byte {
+	secret := generateSharedSecretRFC4753(sender, recipient)
+	keyData := sha512.Sum512(secret)
+
+	iv := make([]byte, 16)
+	block, err := aes.NewCipher(keyData[:32])
+	assert.Nil(t, err)
+
+	cipherText := make([]byte, len(plainText))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(cipherText, plainText)
+
+	msg := []byte{payloadVersion, 0}
+	msg = append(msg, iv...)
+	msg = append(msg, cipherText...)
+
+	mac := hmac.New(sha256.New, keyData[32:])
+	_, err = mac.Write(msg)
+	assert.Nil(t, err)
+
+	msg = append(msg, mac.Sum(nil)...)
+	return append(msg, sender.PubKey().SerializeUncompressed()...)
+}
+
+func encryptionTestKeys(t *testing.T) (*btcec.PrivateKey, *btcec.PrivateKey) {
+	alice, err := NewHDWalletFromWords(w, BaseCoinBip84MainNet).signingPrivateKey()
+	assert.Nil(t, err)
+	bob, err := NewHDWalletFromWords("zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong", BaseCoinBip84MainNet).signingPrivateKey()
+	assert.Nil(t, err)
+	return alice, bob
+}
+
+func TestDecrypt_RoundTrip(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+
+	for _, size := range []int{0, 1, 15, 16, 17, 64} {
+		body := make([]byte, size)
+		enc, err := encrypt(body, alice, bob.PubKey())
+		assert.Nil(t, err)
+
+		dec, err := decrypt(enc, bob)
+		assert.Nil(t, err)
+		assert.Equal(t, body, dec)
+	}
+}
+
+func TestDecrypt_InsufficientData(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+
+	dec, err := decrypt(make([]byte, minPayloadSize-1), bob)
+	assert.Equal(t, ErrInsufficientData, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecrypt_InvalidVersion(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+	enc, err := encrypt([]byte("hey dude"), alice, bob.PubKey())
+	assert.Nil(t, err)
+
+	enc[0] = 2
+	dec, err := decrypt(enc, bob)
+	assert.Equal(t, ErrInvalidPayloadVersion, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecrypt_InvalidOption(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+	enc, err := encrypt([]byte("hey dude"), alice, bob.PubKey())
+	assert.Nil(t, err)
+
+	enc[1] = 1
+	dec, err := decrypt(enc, bob)
+	assert.Equal(t, ErrInvalidPayloadOption, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecrypt_UnalignedCipherText(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+	enc, err := encrypt([]byte("a message that spans two blocks"), alice, bob.PubKey())
+	assert.Nil(t, err)
+
+	// drop one byte from the cipher text, which previously panicked in CryptBlocks
+	unaligned := append([]byte{}, enc[:20]...)
+	unaligned = append(unaligned, enc[21:]...)
+	dec, err := decrypt(unaligned, bob)
+	assert.Equal(t, ErrInvalidCipherTextLength, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecrypt_TamperedCipherText(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+	enc, err := encrypt([]byte("hey dude"), alice, bob.PubKey())
+	assert.Nil(t, err)
+
+	enc[20] ^= 0xff
+	dec, err := decrypt(enc, bob)
+	assert.Equal(t, ErrInvalidHMAC, err)
+	assert.Nil(t, dec)
+}
+
+func TestDecrypt_InvalidPadding(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+
+	badPads := [][]byte{
+		append(make([]byte, 15), 0x00),       // zero pad length
+		append(make([]byte, 15), 0x11),       // pad length larger than block
+		append(make([]byte, 14), 0x01, 0x02), // inconsistent pad bytes
+	}
+
+	for _, plainText := range badPads {
+		payload := sealRawPayload(t, plainText, alice, bob.PubKey())
+		dec, err := decrypt(payload, bob)
+		assert.Equal(t, ErrInvalidPadding, err)
+		assert.Nil(t, dec)
+	}
+}
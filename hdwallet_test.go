@@ -233,6 +233,71 @@ func TestEncryptionWithDefaultKeysEndToEnd(t *testing.T) {
 	assert.Equal(t, messageString, decryptedString)
 }
 
+func TestDecryptMessageWithSender(t *testing.T) {
+	bobWords := "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"
+	messageString := "hey dude"
+
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	aliceCPK, err := aliceWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessage([]byte(messageString), bobCPK)
+	assert.Nil(t, err)
+
+	dec, err := bobWallet.DecryptMessageWithSender(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec.Body))
+	assert.Equal(t, aliceCPK, dec.SenderPublicKey)
+	assert.Equal(t, hex.EncodeToString(enc[len(enc)-65:]), dec.SenderUncompressedPublicKey)
+}
+
+func TestDecryptMessageFromSender_ExpectedSender(t *testing.T) {
+	bobWords := "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"
+	messageString := "hey dude"
+
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	aliceCPK, err := aliceWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessage([]byte(messageString), bobCPK)
+	assert.Nil(t, err)
+
+	dec, err := bobWallet.DecryptMessageFromSender(enc, aliceCPK)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec.Body))
+
+	// uncompressed form of the same key is accepted as well
+	dec, err = bobWallet.DecryptMessageFromSender(enc, dec.SenderUncompressedPublicKey)
+	assert.Nil(t, err)
+	assert.Equal(t, messageString, string(dec.Body))
+}
+
+func TestDecryptMessageFromSender_UnexpectedSender(t *testing.T) {
+	bobWords := "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"
+	carolWords := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	carolWallet := NewHDWalletFromWords(carolWords, BaseCoinBip84MainNet)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	carolCPK, err := carolWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	enc, err := aliceWallet.EncryptMessage([]byte("hey dude"), bobCPK)
+	assert.Nil(t, err)
+
+	dec, err := bobWallet.DecryptMessageFromSender(enc, carolCPK)
+	assert.Equal(t, ErrUnexpectedSender, err)
+	assert.Nil(t, dec)
+}
+
 func TestImportPrivateKey(t *testing.T) {
 	encodedKey := "L2uv4eejGywPPmsESp3N9Vum9HGX6gBg6RTWJ5oakN9HFTiSKB8i"
 	expectedAddress := "1Ad4RSbPrFvo4T5eRMFCoieYf9AuhYdL3h"
@@ -0,0 +1,77 @@
+package cnlib
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// legacyIdentityKeyVersion is the original, non-hardened m/42 identity key.
+const legacyIdentityKeyVersion = 0
+
+const keyRotationStatementPrefix = "cnlib key rotation"
+
+var (
+	// ErrInvalidIdentityKeyVersion describes an error in which an identity key version is negative, or not below hdkeychain.HardenedKeyStart.
+	ErrInvalidIdentityKeyVersion = errors.New("invalid identity key version")
+
+	// ErrInvalidKeyRotation describes an error in which a key rotation statement does not move to a different key.
+	ErrInvalidKeyRotation = errors.New("invalid key rotation")
+)
+
+/// Type Definition
+
+// KeyRotationStatement announces that the identity key OldPublicKey is replaced by NewPublicKey. Both keys sign the
+// canonical string: the old key authorizes the new one, and the new key proves it is controlled by the same owner.
+type KeyRotationStatement struct {
+	OldVersion      int
+	OldPublicKey    string // hex-encoded compressed public key
+	NewVersion      int
+	NewPublicKey    string // hex-encoded compressed public key
+	Timestamp       int64  // unix seconds
+	OldKeySignature string // hex-encoded DER signature
+	NewKeySignature string // hex-encoded DER signature
+}
+
+/// Constructors
+
+// NewKeyRotationStatement creates and returns a pointer to an unsigned KeyRotationStatement.
+func NewKeyRotationStatement(oldVersion int, oldPublicKey string, newVersion int, newPublicKey string, timestamp int64) *KeyRotationStatement {
+	return &KeyRotationStatement{
+		OldVersion:   oldVersion,
+		OldPublicKey: strings.ToLower(oldPublicKey),
+		NewVersion:   newVersion,
+		NewPublicKey: strings.ToLower(newPublicKey),
+		Timestamp:    timestamp,
+	}
+}
+
+/// Receiver methods
+
+// CanonicalString returns the string signed by both keys.
+func (s *KeyRotationStatement) CanonicalString() string {
+	return strings.Join([]string{
+		keyRotationStatementPrefix,
+		strconv.Itoa(s.OldVersion),
+		strings.ToLower(s.OldPublicKey),
+		strconv.Itoa(s.NewVersion),
+		strings.ToLower(s.NewPublicKey),
+		strconv.FormatInt(s.Timestamp, 10),
+	}, "\n")
+}
+
+// Verify checks both signatures on the statement. A server should also check that OldPublicKey is the key it currently trusts.
+func (s *KeyRotationStatement) Verify() (bool, error) {
+	if s.OldVersion == s.NewVersion || strings.EqualFold(s.OldPublicKey, s.NewPublicKey) {
+		return false, ErrInvalidKeyRotation
+	}
+
+	message := []byte(s.CanonicalString())
+
+	valid, err := VerifySignatureHexString(message, s.OldKeySignature, s.OldPublicKey)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	return VerifySignatureHexString(message, s.NewKeySignature, s.NewPublicKey)
+}
@@ -0,0 +1,155 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityPublicKeyForVersion(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	legacy, err := wallet.SigningPublicKey()
+	assert.Nil(t, err)
+	version0, err := wallet.IdentityPublicKeyForVersion(0)
+	assert.Nil(t, err)
+	assert.Equal(t, legacy, version0)
+
+	version1, err := wallet.CoinNinjaVerificationKeyHexStringForVersion(1)
+	assert.Nil(t, err)
+	version2, err := wallet.CoinNinjaVerificationKeyHexStringForVersion(2)
+	assert.Nil(t, err)
+	legacyHex, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	assert.NotEqual(t, legacyHex, version1)
+	assert.NotEqual(t, version1, version2)
+
+	// version n is m/42'/n
+	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	path, err := kf.masterPrivateKey.Child(hardened(42))
+	assert.Nil(t, err)
+	child, err := path.Child(1)
+	assert.Nil(t, err)
+	pub, err := child.ECPubKey()
+	assert.Nil(t, err)
+	version1Bytes, err := wallet.IdentityPublicKeyForVersion(1)
+	assert.Nil(t, err)
+	assert.Equal(t, pub.SerializeCompressed(), version1Bytes)
+
+	_, err = wallet.IdentityPublicKeyForVersion(-1)
+	assert.Equal(t, ErrInvalidIdentityKeyVersion, err)
+
+	// uint32 truncation would otherwise map these onto hardened or low non-hardened children
+	for _, version := range []int64{hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart + 1, 1<<32 + 1} {
+		if int64(int(version)) != version {
+			continue
+		}
+		_, err = wallet.IdentityPublicKeyForVersion(int(version))
+		assert.Equal(t, ErrInvalidIdentityKeyVersion, err)
+		_, err = wallet.SignatureSigningDataForVersion(int(version), []byte("Hello World"))
+		assert.Equal(t, ErrInvalidIdentityKeyVersion, err)
+	}
+}
+
+func TestSignDataForVersion(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	message := []byte("Hello World")
+
+	legacySig, err := wallet.SignatureSigningData(message)
+	assert.Nil(t, err)
+	version0Sig, err := wallet.SignatureSigningDataForVersion(0, message)
+	assert.Nil(t, err)
+	assert.Equal(t, legacySig, version0Sig)
+
+	sig, err := wallet.SignatureSigningDataForVersion(3, message)
+	assert.Nil(t, err)
+	pubkey, err := wallet.CoinNinjaVerificationKeyHexStringForVersion(3)
+	assert.Nil(t, err)
+	valid, err := VerifySignatureHexString(message, sig, pubkey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	legacyPubkey, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	valid, err = VerifySignatureHexString(message, sig, legacyPubkey)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
+
+func TestEncryptMessageForVersion(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+
+	bobKey, err := bobWallet.identityPrivateKey(2)
+	assert.Nil(t, err)
+	bobPubkey := bobKey.PubKey().SerializeUncompressed()
+
+	enc, err := aliceWallet.EncryptMessageForVersion(1, []byte("hey dude"), hex.EncodeToString(bobPubkey))
+	assert.Nil(t, err)
+
+	dec, err := bobWallet.DecryptMessageForVersion(2, enc)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hey dude"), dec.Body)
+	aliceVersion1, err := aliceWallet.CoinNinjaVerificationKeyHexStringForVersion(1)
+	assert.Nil(t, err)
+	assert.Equal(t, aliceVersion1, dec.SenderPublicKey)
+
+	_, err = bobWallet.DecryptMessageForVersion(0, enc)
+	assert.Equal(t, ErrInvalidHMAC, err)
+}
+
+func TestSignKeyRotation(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	statement, err := wallet.SignKeyRotation(0, 1, 1560000000)
+	assert.Nil(t, err)
+
+	oldPubkey, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	newPubkey, err := wallet.CoinNinjaVerificationKeyHexStringForVersion(1)
+	assert.Nil(t, err)
+	assert.Equal(t, oldPubkey, statement.OldPublicKey)
+	assert.Equal(t, newPubkey, statement.NewPublicKey)
+
+	valid, err := statement.Verify()
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	// a server only holding the parts can rebuild and verify the statement
+	rebuilt := NewKeyRotationStatement(0, oldPubkey, 1, newPubkey, 1560000000)
+	rebuilt.OldKeySignature = statement.OldKeySignature
+	rebuilt.NewKeySignature = statement.NewKeySignature
+	valid, err = rebuilt.Verify()
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	rebuilt.Timestamp++
+	valid, err = rebuilt.Verify()
+	assert.Nil(t, err)
+	assert.False(t, valid)
+
+	_, err = wallet.SignKeyRotation(1, 1, 1560000000)
+	assert.Equal(t, ErrInvalidKeyRotation, err)
+}
+
+func TestKeyRotationStatement_RejectsSwappedSignatures(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	other := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+
+	statement, err := wallet.SignKeyRotation(1, 2, 1560000000)
+	assert.Nil(t, err)
+
+	// a key not authorized by the old key cannot be substituted
+	otherPubkey, err := other.CoinNinjaVerificationKeyHexStringForVersion(2)
+	assert.Nil(t, err)
+	forged := NewKeyRotationStatement(1, statement.OldPublicKey, 2, otherPubkey, 1560000000)
+	forged.OldKeySignature = statement.OldKeySignature
+	forged.NewKeySignature, err = other.SignatureSigningDataForVersion(2, []byte(forged.CanonicalString()))
+	assert.Nil(t, err)
+
+	valid, err := forged.Verify()
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
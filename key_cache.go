@@ -0,0 +1,117 @@
+package cnlib
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+/// Type Definition
+
+// keyCache holds account and chain level extended keys, so deriving an address costs one non-hardened derivation
+// instead of three hardened and two non-hardened ones. Keys are cached per BaseCoin value, and the cache is safe for
+// concurrent use.
+type keyCache struct {
+	mu       sync.Mutex
+	accounts map[accountCacheKey]*hdkeychain.ExtendedKey
+	chains   map[chainCacheKey]*hdkeychain.ExtendedKey
+}
+
+type accountCacheKey struct {
+	purpose int
+	coin    int
+	account int
+}
+
+type chainCacheKey struct {
+	accountCacheKey
+	public bool
+	change int
+}
+
+/// Constructors
+
+func newKeyCache() *keyCache {
+	return &keyCache{
+		accounts: make(map[accountCacheKey]*hdkeychain.ExtendedKey),
+		chains:   make(map[chainCacheKey]*hdkeychain.ExtendedKey),
+	}
+}
+
+/// Receiver methods
+
+// privateChainKey returns the m/purpose'/coin'/account'/change extended private key for path, deriving the account key with kf.
+func (c *keyCache) privateChainKey(kf keyFactory, path *DerivationPath) (*hdkeychain.ExtendedKey, error) {
+	acctKey := accountCacheKey{purpose: path.Purpose, coin: path.Coin, account: path.Account}
+	key := chainCacheKey{accountCacheKey: acctKey, change: path.Change}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if chain, ok := c.chains[key]; ok {
+		return chain, nil
+	}
+
+	account, ok := c.accounts[acctKey]
+	if !ok {
+		derived, err := kf.accountPrivateKey(path.BaseCoin)
+		if err != nil {
+			return nil, err
+		}
+		account = derived
+		primePublicKey(account)
+		c.accounts[acctKey] = account
+	}
+
+	chain, err := account.Child(uint32(path.Change))
+	if err != nil {
+		return nil, err
+	}
+	primePublicKey(chain)
+	c.chains[key] = chain
+	return chain, nil
+}
+
+// publicChainKey returns the change chain extended public key below a watch-only wallet's account key.
+func (c *keyCache) publicChainKey(accountKey *hdkeychain.ExtendedKey, basecoin *BaseCoin, change uint32) (*hdkeychain.ExtendedKey, error) {
+	key := chainCacheKey{
+		accountCacheKey: accountCacheKey{purpose: basecoin.Purpose, coin: basecoin.Coin, account: basecoin.Account},
+		public:          true,
+		change:          int(change),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if chain, ok := c.chains[key]; ok {
+		return chain, nil
+	}
+
+	chain, err := accountKey.Child(change)
+	if err != nil {
+		return nil, err
+	}
+	c.chains[key] = chain
+	return chain, nil
+}
+
+// clear drops every cached key. It is a no-op on a nil cache.
+func (c *keyCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts = make(map[accountCacheKey]*hdkeychain.ExtendedKey)
+	c.chains = make(map[chainCacheKey]*hdkeychain.ExtendedKey)
+}
+
+/// Unexported functions
+
+// primePublicKey computes a private extended key's public key up front. hdkeychain fills it in lazily on the first
+// Child call, which is a data race once the key is shared between goroutines.
+func primePublicKey(key *hdkeychain.ExtendedKey) {
+	if key != nil && key.IsPrivate() {
+		_, _ = key.ECPubKey()
+	}
+}
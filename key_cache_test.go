@@ -0,0 +1,138 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func uncachedWallet(wallet *HDWallet) *HDWallet {
+	return &HDWallet{baseCoin: wallet.coin(), WalletWords: wallet.WalletWords, masterPrivateKey: wallet.masterPrivateKey, accountPublicKey: wallet.accountPublicKey}
+}
+
+func TestKeyCache_MatchesUncachedDerivation(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	uncached := uncachedWallet(wallet)
+
+	for i := 0; i < 5; i++ {
+		cachedAddr, err := wallet.ChangeAddressForIndex(i)
+		assert.Nil(t, err)
+		uncachedAddr, err := uncached.ChangeAddressForIndex(i)
+		assert.Nil(t, err)
+		assert.Equal(t, uncachedAddr, cachedAddr)
+	}
+
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 7)
+	cachedKey, err := wallet.CompressedPubKeyForPath(path)
+	assert.Nil(t, err)
+	uncachedKey, err := uncached.CompressedPubKeyForPath(path)
+	assert.Nil(t, err)
+	assert.Equal(t, uncachedKey, cachedKey)
+}
+
+func TestKeyCache_UpdateCoinInvalidates(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	_, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(wallet.keyCache.chains))
+
+	wallet.UpdateCoin(BaseCoinBip49MainNet)
+	assert.Equal(t, 0, len(wallet.keyCache.chains))
+	assert.Equal(t, 0, len(wallet.keyCache.accounts))
+
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf", ma.Address)
+}
+
+func TestKeyCache_KeyedByBaseCoinValue(t *testing.T) {
+	// the wallet holds its own copy of the BaseCoin, so switching accounts goes through UpdateCoin
+	wallet := NewHDWalletFromWords(w, NewBaseCoin(84, 0, 0))
+	first, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+
+	wallet.UpdateCoin(NewBaseCoin(84, 0, 1))
+	second, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Address, second.Address)
+
+	expected, err := NewHDWalletFromWords(w, NewBaseCoin(84, 0, 1)).ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.Address, second.Address)
+}
+
+func TestReceiveAddressesForRange(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	list, err := wallet.ReceiveAddressesForRange(3, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, list.Count())
+	for i := 0; i < list.Count(); i++ {
+		ma, err := wallet.ReceiveAddressForIndex(3 + i)
+		assert.Nil(t, err)
+		assert.Equal(t, ma, list.MetaAddressAtIndex(i))
+	}
+
+	list, err = wallet.ChangeAddressesForRange(0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", list.MetaAddressAtIndex(0).Address)
+
+	_, err = wallet.ReceiveAddressesForRange(-1, 2)
+	assert.NotNil(t, err)
+}
+
+func TestReceiveAddressesForRange_WatchOnly(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	zpub, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	watchOnly, err := NewHDWalletFromAccountExtendedPublicKey(zpub)
+	assert.Nil(t, err)
+
+	list, err := watchOnly.ReceiveAddressesForRange(0, 3)
+	assert.Nil(t, err)
+	expected, err := wallet.ReceiveAddressesForRange(0, 3)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, expected.MetaAddressAtIndex(i).Address, list.MetaAddressAtIndex(i).Address)
+	}
+}
+
+func BenchmarkReceiveAddressForIndex_Uncached(b *testing.B) {
+	wallet := uncachedWallet(NewHDWalletFromWords(w, BaseCoinBip84MainNet))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := wallet.ReceiveAddressForIndex(i % 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReceiveAddressForIndex_Cached(b *testing.B) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := wallet.ReceiveAddressForIndex(i % 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCheckForAddress_Uncached(b *testing.B) {
+	wallet := uncachedWallet(NewHDWalletFromWords(w, BaseCoinBip84MainNet))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := wallet.CheckForAddress("bc1qnotfound", 20); err == nil {
+			b.Fatal("expected not found")
+		}
+	}
+}
+
+func BenchmarkCheckForAddress_Cached(b *testing.B) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := wallet.CheckForAddress("bc1qnotfound", 20); err == nil {
+			b.Fatal("expected not found")
+		}
+	}
+}
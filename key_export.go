@@ -0,0 +1,92 @@
+package cnlib
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// privkeyTypes maps each SLIP-132 public prefix to its private counterpart.
+var privkeyTypes = map[string]string{
+	xpub: xprv,
+	ypub: yprv,
+	zpub: zprv,
+	tpub: tprv,
+	upub: uprv,
+	vpub: vprv,
+}
+
+var (
+	// ErrPrivateKeyExportNotAllowed describes an error in which a private key export was attempted without opting in
+	// through KeyExportOptions.
+	ErrPrivateKeyExportNotAllowed = errors.New("private key export not allowed by key export options")
+)
+
+/// Type Definition
+
+// KeyExportOptions gates private key export. Exports fail unless AllowPrivateKeyExport is set, so secrets cannot
+// leave the library by accident.
+type KeyExportOptions struct {
+	AllowPrivateKeyExport bool
+}
+
+/// Constructors
+
+// NewKeyExportOptions returns a pointer to KeyExportOptions with the given opt-in.
+func NewKeyExportOptions(allowPrivateKeyExport bool) *KeyExportOptions {
+	return &KeyExportOptions{AllowPrivateKeyExport: allowPrivateKeyExport}
+}
+
+/// Receiver methods
+
+// ExportPrivateKeyWIF returns the WIF encoded private key for a derivation path, flagged compressed as every address
+// type this library builds uses compressed public keys, and encoded for the path's network.
+func (wallet *HDWallet) ExportPrivateKeyWIF(path *DerivationPath, options *KeyExportOptions) (string, error) {
+	if !options.allowsPrivateKeyExport() {
+		return "", ErrPrivateKeyExportNotAllowed
+	}
+	if path == nil || path.BaseCoin == nil {
+		return "", errors.New("derivation path cannot be nil")
+	}
+
+	kf := wallet.keyFactory()
+	indexKey, err := kf.indexPrivateKey(path)
+	if err != nil {
+		return "", err
+	}
+	privKey, err := indexKey.ECPrivKey()
+	if err != nil {
+		return "", err
+	}
+
+	wif, err := btcutil.NewWIF(privKey, path.BaseCoin.defaultNetParams(), true)
+	if err != nil {
+		return "", err
+	}
+	return wif.String(), nil
+}
+
+// ExportAccountExtendedPrivateKey returns the account-level extended private key for the current BaseCoin, with the
+// x/y/zprv (or t/u/vprv) prefix matching AccountExtendedMasterPublicKey.
+func (wallet *HDWallet) ExportAccountExtendedPrivateKey(options *KeyExportOptions) (string, error) {
+	if !options.allowsPrivateKeyExport() {
+		return "", ErrPrivateKeyExportNotAllowed
+	}
+
+	basecoin := wallet.coin()
+	pubType, err := basecoin.defaultExtendedPubkeyType()
+	if err != nil {
+		return "", err
+	}
+
+	kf := wallet.keyFactory()
+	accountKey, err := kf.accountPrivateKey(basecoin)
+	if err != nil {
+		return "", err
+	}
+	return ConvertExtendedKey(accountKey.String(), privkeyTypes[pubType])
+}
+
+func (options *KeyExportOptions) allowsPrivateKeyExport() bool {
+	return options != nil && options.AllowPrivateKeyExport
+}
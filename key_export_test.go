@@ -0,0 +1,85 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportPrivateKeyWIF(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 0)
+
+	// BIP84 test vector, m/84'/0'/0'/0/0
+	key, err := wallet.ExportPrivateKeyWIF(path, NewKeyExportOptions(true))
+	assert.Nil(t, err)
+	assert.Equal(t, "KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d", key)
+}
+
+func TestExportPrivateKeyWIF_MatchesPublicKey(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip49TestNet)
+	path := NewDerivationPath(BaseCoinBip49TestNet, 1, 4)
+
+	key, err := wallet.ExportPrivateKeyWIF(path, NewKeyExportOptions(true))
+	assert.Nil(t, err)
+
+	wif, err := btcutil.DecodeWIF(key)
+	assert.Nil(t, err)
+	assert.True(t, wif.CompressPubKey)
+	assert.True(t, wif.IsForNet(BaseCoinBip49TestNet.defaultNetParams()))
+
+	expected, _ := wallet.CompressedPubKeyForPath(path)
+	assert.Equal(t, expected, wif.SerializePubKey())
+}
+
+func TestExportPrivateKeyWIF_RequiresOptIn(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 0)
+
+	key, err := wallet.ExportPrivateKeyWIF(path, nil)
+	assert.Equal(t, ErrPrivateKeyExportNotAllowed, err)
+	assert.Equal(t, "", key)
+
+	key, err = wallet.ExportPrivateKeyWIF(path, NewKeyExportOptions(false))
+	assert.Equal(t, ErrPrivateKeyExportNotAllowed, err)
+	assert.Equal(t, "", key)
+}
+
+func TestExportPrivateKeyWIF_WatchOnlyFails(t *testing.T) {
+	wallet, err := NewHDWalletFromAccountExtendedPublicKey(bip84AccountZpub)
+	assert.Nil(t, err)
+
+	_, err = wallet.ExportPrivateKeyWIF(NewDerivationPath(BaseCoinBip84MainNet, 0, 0), NewKeyExportOptions(true))
+	assert.NotNil(t, err)
+}
+
+func TestExportAccountExtendedPrivateKey(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	key, err := wallet.ExportAccountExtendedPrivateKey(NewKeyExportOptions(true))
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZprv, key)
+
+	wallet.UpdateCoin(NewBaseCoin(86, 0, 0))
+	key, err = wallet.ExportAccountExtendedPrivateKey(NewKeyExportOptions(true))
+	assert.Nil(t, err)
+	assert.Equal(t, xprv, key[:4])
+}
+
+func TestExportAccountExtendedPrivateKey_RoundTripsAccountWallet(t *testing.T) {
+	wallet, err := NewHDWalletFromAccountExtendedPrivateKey(bip84AccountZprv)
+	assert.Nil(t, err)
+
+	key, err := wallet.ExportAccountExtendedPrivateKey(NewKeyExportOptions(true))
+	assert.Nil(t, err)
+	assert.Equal(t, bip84AccountZprv, key)
+}
+
+func TestExportAccountExtendedPrivateKey_RequiresOptIn(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+
+	key, err := wallet.ExportAccountExtendedPrivateKey(&KeyExportOptions{})
+	assert.Equal(t, ErrPrivateKeyExportNotAllowed, err)
+	assert.Equal(t, "", key)
+}
@@ -11,10 +11,13 @@ import (
 
 /// Type Definition
 
-// KeyFactory is a struct holding optional refs to masterPrivateKey, and acctExtPubKey, with receiver methods to obtain keys relative to the wallet.
+// KeyFactory is a struct holding optional refs to masterPrivateKey, acctExtPrivKey and acctExtPubKey, with receiver methods to obtain keys relative to the wallet.
 type keyFactory struct {
 	masterPrivateKey *hdkeychain.ExtendedKey
+	acctExtPrivKey   *hdkeychain.ExtendedKey // used when there is no master key
+	acctCoin         *BaseCoin               // the account acctExtPrivKey belongs to
 	acctExtPubKey    *hdkeychain.ExtendedKey
+	cache            *keyCache // optional
 }
 
 var pubkeyIDs = map[string][]byte{
@@ -29,27 +32,55 @@ var pubkeyIDs = map[string][]byte{
 /// Receiver methods
 
 func (kf keyFactory) indexPrivateKey(path *DerivationPath) (*hdkeychain.ExtendedKey, error) {
-	purposeKey, err := kf.masterPrivateKey.Child(hardened(path.Purpose))
-	if err != nil {
-		return nil, err
+	if kf.masterPrivateKey == nil && kf.acctExtPrivKey == nil {
+		return nil, errors.New("missing master private key")
 	}
-	coinKey, err := purposeKey.Child(hardened(path.Coin))
-	if err != nil {
-		return nil, err
+
+	var changeKey *hdkeychain.ExtendedKey
+	if kf.cache != nil {
+		chain, err := kf.cache.privateChainKey(kf, path)
+		if err != nil {
+			return nil, err
+		}
+		changeKey = chain
+	} else {
+		accountKey, err := kf.accountPrivateKey(path.BaseCoin)
+		if err != nil {
+			return nil, err
+		}
+		changeKey, err = accountKey.Child(uint32(path.Change))
+		if err != nil {
+			return nil, err
+		}
 	}
-	accountKey, err := coinKey.Child(hardened(path.Account))
+
+	indexKey, err := changeKey.Child(uint32(path.Index))
 	if err != nil {
 		return nil, err
 	}
-	changeKey, err := accountKey.Child(uint32(path.Change))
+	return indexKey, nil
+}
+
+// accountPrivateKey returns the m/purpose'/coin'/account' extended private key.
+func (kf keyFactory) accountPrivateKey(bc *BaseCoin) (*hdkeychain.ExtendedKey, error) {
+	if kf.masterPrivateKey == nil {
+		if kf.acctExtPrivKey == nil {
+			return nil, errors.New("missing master private key")
+		}
+		if *bc != *kf.acctCoin {
+			return nil, ErrPathOutsideAccount
+		}
+		return kf.acctExtPrivKey, nil
+	}
+	purposeKey, err := kf.masterPrivateKey.Child(hardened(bc.Purpose))
 	if err != nil {
 		return nil, err
 	}
-	indexKey, err := changeKey.Child(uint32(path.Index))
+	coinKey, err := purposeKey.Child(hardened(bc.Coin))
 	if err != nil {
 		return nil, err
 	}
-	return indexKey, nil
+	return coinKey.Child(hardened(bc.Account))
 }
 
 // accountExtendedPublicKey returns the extended public key and its stringified version.
@@ -58,15 +89,7 @@ func (kf keyFactory) accountExtendedPublicKey(bc *BaseCoin) (*hdkeychain.Extende
 
 	if kf.masterPrivateKey != nil {
 		// derive account child
-		purposeKey, err := kf.masterPrivateKey.Child(hardened(bc.Purpose))
-		if err != nil {
-			return nil, "", err
-		}
-		coinKey, err := purposeKey.Child(hardened(bc.Coin))
-		if err != nil {
-			return nil, "", err
-		}
-		accountKey, err := coinKey.Child(hardened(bc.Account))
+		accountKey, err := kf.accountPrivateKey(bc)
 		if err != nil {
 			return nil, "", err
 		}
@@ -115,7 +138,7 @@ func (kf keyFactory) accountExtendedPublicKey(bc *BaseCoin) (*hdkeychain.Extende
 func (kf keyFactory) signingMasterKey() (*hdkeychain.ExtendedKey, error) {
 	masterKey := kf.masterPrivateKey
 	if masterKey == nil {
-		return nil, errors.New("missing master private key")
+		return nil, kf.missingMasterKeyError()
 	}
 	childKey, err := masterKey.Child(42)
 	if err != nil {
@@ -124,10 +147,43 @@ func (kf keyFactory) signingMasterKey() (*hdkeychain.ExtendedKey, error) {
 	return childKey, nil
 }
 
+// identityKey returns the versioned identity key: version 0 is the legacy m/42 key, and version n > 0 is m/42'/n.
+// Versions must be below hdkeychain.HardenedKeyStart, so m/42'/n is never itself a hardened child.
+func (kf keyFactory) identityKey(version int) (*hdkeychain.ExtendedKey, error) {
+	if version < 0 || int64(version) >= hdkeychain.HardenedKeyStart {
+		return nil, ErrInvalidIdentityKeyVersion
+	}
+	if version == legacyIdentityKeyVersion {
+		return kf.signingMasterKey()
+	}
+
+	masterKey := kf.masterPrivateKey
+	if masterKey == nil {
+		return nil, kf.missingMasterKeyError()
+	}
+	identityRoot, err := masterKey.Child(hardened(42))
+	if err != nil {
+		return nil, err
+	}
+	return identityRoot.Child(uint32(version))
+}
+
+// missingMasterKeyError tells an account-key wallet apart from one with no private keys at all.
+func (kf keyFactory) missingMasterKeyError() error {
+	if kf.acctExtPrivKey != nil {
+		return ErrMasterKeyRequired
+	}
+	return errors.New("missing master private key")
+}
+
 func (kf keyFactory) signData(message []byte) ([]byte, error) {
+	return kf.signDataForVersion(legacyIdentityKeyVersion, message)
+}
+
+func (kf keyFactory) signDataForVersion(version int, message []byte) ([]byte, error) {
 	messageHash := chainhash.DoubleHashB(message)
 
-	key, err := kf.signingMasterKey()
+	key, err := kf.identityKey(version)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +209,11 @@ func (kf keyFactory) signData(message []byte) ([]byte, error) {
 }
 
 func (kf keyFactory) signatureSigningData(message []byte) (string, error) {
-	sign, err := kf.signData(message)
+	return kf.signatureSigningDataForVersion(legacyIdentityKeyVersion, message)
+}
+
+func (kf keyFactory) signatureSigningDataForVersion(version int, message []byte) (string, error) {
+	sign, err := kf.signDataForVersion(version, message)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,235 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const fingerprintSize = 4
+
+var (
+	// ErrInvalidKeyOrigin describes an error in which a key origin's fingerprint or path cannot be parsed, or does not match its key.
+	ErrInvalidKeyOrigin = errors.New("invalid key origin")
+
+	// ErrMissingKeyOrigin describes an error in which a watch-only wallet was created without a key origin, or with one for
+	// a different account than its current BaseCoin.
+	ErrMissingKeyOrigin = errors.New("wallet has no key origin")
+)
+
+/// Type Definition
+
+// KeyOrigin is the master key fingerprint and derivation path of a key, as used in descriptors and PSBTs.
+type KeyOrigin struct {
+	fingerprint []byte
+	path        []uint32
+}
+
+/// Constructors
+
+// NewKeyOrigin returns a pointer to a KeyOrigin from a hex fingerprint and a path such as "m/84'/0'/0'" (the "m/" is optional).
+func NewKeyOrigin(fingerprint string, path string) (*KeyOrigin, error) {
+	fp, err := hex.DecodeString(fingerprint)
+	if err != nil || len(fp) != fingerprintSize {
+		return nil, ErrInvalidKeyOrigin
+	}
+
+	origin := &KeyOrigin{fingerprint: fp}
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+	if path == "" {
+		return origin, nil
+	}
+	for _, part := range strings.Split(path, "/") {
+		index, err := parseDerivationIndex(part)
+		if err != nil {
+			return nil, ErrInvalidKeyOrigin
+		}
+		origin.path = append(origin.path, index)
+	}
+	return origin, nil
+}
+
+// ParseKeyOrigin parses an origin in descriptor notation, with or without brackets, e.g. "[73c5da0a/84'/0'/0']".
+func ParseKeyOrigin(origin string) (*KeyOrigin, error) {
+	origin = strings.TrimSuffix(strings.TrimPrefix(origin, "["), "]")
+	sep := strings.IndexByte(origin, '/')
+	if sep < 0 {
+		return NewKeyOrigin(origin, "")
+	}
+	return NewKeyOrigin(origin[:sep], origin[sep+1:])
+}
+
+// NewHDWalletFromAccountExtendedPublicKeyWithOrigin returns a watch-only HDWallet that also knows where its account key
+// came from. The origin must be the hardened purpose'/coin'/account' path of the key, and decides the BaseCoin, so a
+// plain xpub can be used for any purpose.
+func NewHDWalletFromAccountExtendedPublicKeyWithOrigin(acctPubKeyStr string, origin *KeyOrigin) (*HDWallet, error) {
+	key, err := hdkeychain.NewKeyFromString(acctPubKeyStr)
+	if err != nil {
+		return nil, err
+	}
+	prefixCoin, err := NewBaseCoinFromAccountPubKey(acctPubKeyStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if origin == nil || len(origin.path) != 3 || key.IsPrivate() || key.Depth() != 3 {
+		return nil, ErrInvalidKeyOrigin
+	}
+	for _, index := range origin.path {
+		if index < hdkeychain.HardenedKeyStart {
+			return nil, ErrInvalidKeyOrigin
+		}
+	}
+	basecoin := NewBaseCoin(
+		int(origin.path[0]-hdkeychain.HardenedKeyStart),
+		int(origin.path[1]-hdkeychain.HardenedKeyStart),
+		int(origin.path[2]-hdkeychain.HardenedKeyStart),
+	)
+
+	// xpub/tpub say nothing about the purpose, but ypub/zpub/upub/vpub do
+	if basecoin.Coin != prefixCoin.Coin || origin.path[2] != extendedKeyChildNumber(key) {
+		return nil, ErrInvalidKeyOrigin
+	}
+	if prefixCoin.Purpose != bip44purpose && prefixCoin.Purpose != basecoin.Purpose {
+		return nil, ErrInvalidKeyOrigin
+	}
+
+	wallet := HDWallet{baseCoin: basecoin, WalletWords: "", masterPrivateKey: nil, accountPublicKey: key, accountKeyOrigin: origin.copy(), keyCache: newKeyCache()}
+	return &wallet, nil
+}
+
+// NewHDWalletFromOriginAnnotatedExtendedPublicKey returns a watch-only HDWallet from an account key prefixed with its origin,
+// e.g. "[73c5da0a/84'/0'/0']xpub6CatWdiZ...", as exported by hardware wallets.
+func NewHDWalletFromOriginAnnotatedExtendedPublicKey(annotated string) (*HDWallet, error) {
+	end := strings.IndexByte(annotated, ']')
+	if !strings.HasPrefix(annotated, "[") || end < 0 {
+		return nil, ErrInvalidKeyOrigin
+	}
+	origin, err := ParseKeyOrigin(annotated[:end+1])
+	if err != nil {
+		return nil, err
+	}
+	return NewHDWalletFromAccountExtendedPublicKeyWithOrigin(annotated[end+1:], origin)
+}
+
+/// Receiver methods
+
+// Fingerprint returns the hex-encoded master key fingerprint.
+func (o *KeyOrigin) Fingerprint() string {
+	return hex.EncodeToString(o.fingerprint)
+}
+
+// Path returns the derivation path from the master key, e.g. "m/84'/0'/0'/0/5".
+func (o *KeyOrigin) Path() string {
+	parts := []string{"m"}
+	for _, index := range o.path {
+		if index >= hdkeychain.HardenedKeyStart {
+			parts = append(parts, strconv.FormatUint(uint64(index-hdkeychain.HardenedKeyStart), 10)+"'")
+		} else {
+			parts = append(parts, strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// String returns the origin in descriptor notation, without brackets, e.g. "73c5da0a/84'/0'/0'".
+func (o *KeyOrigin) String() string {
+	return o.Fingerprint() + strings.TrimPrefix(o.Path(), "m")
+}
+
+// MasterFingerprint returns the hex-encoded fingerprint of the master key, or of the origin a watch-only wallet was created with.
+func (wallet *HDWallet) MasterFingerprint() (string, error) {
+	fingerprint, err := wallet.masterFingerprint()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fingerprint), nil
+}
+
+// AccountKeyOrigin returns the key origin of the current BaseCoin's account key.
+func (wallet *HDWallet) AccountKeyOrigin() (*KeyOrigin, error) {
+	return wallet.accountKeyOriginForCoin(wallet.coin())
+}
+
+/// Unexported functions
+
+func (o *KeyOrigin) copy() *KeyOrigin {
+	return &KeyOrigin{fingerprint: append([]byte{}, o.fingerprint...), path: append([]uint32{}, o.path...)}
+}
+
+func (o *KeyOrigin) child(indexes ...uint32) *KeyOrigin {
+	c := o.copy()
+	c.path = append(c.path, indexes...)
+	return c
+}
+
+func (wallet *HDWallet) masterFingerprint() ([]byte, error) {
+	if wallet.fingerprint != nil {
+		return wallet.fingerprint, nil
+	}
+	if wallet.masterPrivateKey != nil {
+		return extendedKeyFingerprint(wallet.masterPrivateKey)
+	}
+	if wallet.accountKeyOrigin != nil {
+		return wallet.accountKeyOrigin.fingerprint, nil
+	}
+	return nil, ErrMissingKeyOrigin
+}
+
+func (wallet *HDWallet) accountKeyOriginForCoin(basecoin *BaseCoin) (*KeyOrigin, error) {
+	path := []uint32{hardened(basecoin.Purpose), hardened(basecoin.Coin), hardened(basecoin.Account)}
+	if wallet.masterPrivateKey == nil {
+		// a watch-only wallet has a single account key, so its origin only describes the coin it was created with
+		if wallet.accountKeyOrigin == nil || !equalPaths(wallet.accountKeyOrigin.path, path) {
+			return nil, ErrMissingKeyOrigin
+		}
+		return wallet.accountKeyOrigin.copy(), nil
+	}
+
+	fingerprint, err := wallet.masterFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	return &KeyOrigin{fingerprint: fingerprint, path: path}, nil
+}
+
+func equalPaths(a []uint32, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDerivationIndex parses a path element, where a trailing ' or h marks a hardened index.
+func parseDerivationIndex(part string) (uint32, error) {
+	isHardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+	if isHardened {
+		part = part[:len(part)-1]
+	}
+	index, err := strconv.ParseUint(part, 10, 32)
+	if err != nil || uint32(index) >= hdkeychain.HardenedKeyStart {
+		return 0, errors.New("invalid derivation index")
+	}
+	if isHardened {
+		return uint32(index) + hdkeychain.HardenedKeyStart, nil
+	}
+	return uint32(index), nil
+}
+
+// extendedKeyFingerprint returns the first 4 bytes of the hash160 of an extended key's public key.
+func extendedKeyFingerprint(key *hdkeychain.ExtendedKey) ([]byte, error) {
+	pub, err := key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	return btcutil.Hash160(pub.SerializeCompressed())[:fingerprintSize], nil
+}
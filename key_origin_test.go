@@ -0,0 +1,143 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasterFingerprint(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	fingerprint, err := wallet.MasterFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a", fingerprint)
+
+	// the fingerprint depends on the seed, not the coin
+	wallet.UpdateCoin(BaseCoinBip49TestNet)
+	fingerprint, err = wallet.MasterFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a", fingerprint)
+
+	zpub, err := NewHDWalletFromWords(w, BaseCoinBip84MainNet).AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	watchOnly, err := NewHDWalletFromAccountExtendedPublicKey(zpub)
+	assert.Nil(t, err)
+	_, err = watchOnly.MasterFingerprint()
+	assert.Equal(t, ErrMissingKeyOrigin, err)
+	_, err = watchOnly.AccountKeyOrigin()
+	assert.Equal(t, ErrMissingKeyOrigin, err)
+}
+
+func TestKeyOrigin_Parse(t *testing.T) {
+	origin, err := ParseKeyOrigin("[73c5da0a/84'/0'/0']")
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a", origin.Fingerprint())
+	assert.Equal(t, "m/84'/0'/0'", origin.Path())
+	assert.Equal(t, "73c5da0a/84'/0'/0'", origin.String())
+
+	origin, err = ParseKeyOrigin("d34db33f/49h/1h/2h/1/7")
+	assert.Nil(t, err)
+	assert.Equal(t, "d34db33f/49'/1'/2'/1/7", origin.String())
+
+	origin, err = NewKeyOrigin("D34DB33F", "m/86'/0'/0'")
+	assert.Nil(t, err)
+	assert.Equal(t, "d34db33f/86'/0'/0'", origin.String())
+
+	origin, err = ParseKeyOrigin("73c5da0a")
+	assert.Nil(t, err)
+	assert.Equal(t, "m", origin.Path())
+
+	for _, invalid := range []string{"", "73c5da", "zzzzzzzz/84'", "73c5da0a/84''", "73c5da0a/x", "73c5da0a/2147483648"} {
+		_, err := ParseKeyOrigin(invalid)
+		assert.Equalf(t, ErrInvalidKeyOrigin, err, invalid)
+	}
+}
+
+func TestMetaAddress_KeyOrigin(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	ma, err := wallet.ChangeAddressForIndex(5)
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a/84'/0'/0'/1/5", ma.KeyOrigin.String())
+
+	origin, err := wallet.AccountKeyOrigin()
+	assert.Nil(t, err)
+	assert.Equal(t, "m/84'/0'/0'", origin.Path())
+
+	wallet.UpdateCoin(NewBaseCoin(49, 1, 3))
+	ma, err = wallet.ReceiveAddressForIndex(2)
+	assert.Nil(t, err)
+	assert.Equal(t, "m/49'/1'/3'/0/2", ma.KeyOrigin.Path())
+}
+
+func TestNewHDWalletFromOriginAnnotatedExtendedPublicKey(t *testing.T) {
+	// a plain xpub with an 86' origin, as exported by a hardware wallet
+	annotated := "[73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ"
+	wallet, err := NewHDWalletFromOriginAnnotatedExtendedPublicKey(annotated)
+	assert.Nil(t, err)
+	assert.Equal(t, NewBaseCoin(86, 0, 0), wallet.BaseCoin())
+
+	ma, err := wallet.MessageSigningAddressForIndex(0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", ma.Address)
+	assert.Equal(t, "73c5da0a/86'/0'/0'/0/0", ma.KeyOrigin.String())
+
+	fingerprint, err := wallet.MasterFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a", fingerprint)
+
+	// a zpub must agree with its origin's purpose
+	zpub := "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	origin, err := ParseKeyOrigin("73c5da0a/84'/0'/0'")
+	assert.Nil(t, err)
+	wallet, err = NewHDWalletFromAccountExtendedPublicKeyWithOrigin(zpub, origin)
+	assert.Nil(t, err)
+	ma, err = wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", ma.Address)
+
+	invalid := []string{
+		"[73c5da0a/49'/0'/0']" + zpub, // purpose mismatch
+		"[73c5da0a/84'/1'/0']" + zpub, // network mismatch
+		"[73c5da0a/84'/0'/1']" + zpub, // account mismatch
+		"[73c5da0a/84'/0'/0]" + zpub,  // unhardened account
+		"[73c5da0a/84'/0']" + zpub,    // too short
+		"73c5da0a/84'/0'/0'" + zpub,   // no brackets
+	}
+	for _, s := range invalid {
+		_, err := NewHDWalletFromOriginAnnotatedExtendedPublicKey(s)
+		assert.NotNilf(t, err, s)
+	}
+}
+
+func TestAccountKeyOrigin_WatchOnlyUpdateCoin(t *testing.T) {
+	zpub := "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	wallet, err := NewHDWalletFromOriginAnnotatedExtendedPublicKey("[73c5da0a/84'/0'/0']" + zpub)
+	assert.Nil(t, err)
+
+	desc, err := wallet.ReceiveOutputDescriptor()
+	assert.Nil(t, err)
+	assert.Contains(t, desc, "[73c5da0a/84'/0'/0']")
+
+	// the stored origin belongs to account 0, so it is not reported for account 1
+	wallet.UpdateCoin(NewBaseCoin(84, 0, 1))
+	origin, err := wallet.AccountKeyOrigin()
+	assert.Equal(t, ErrMissingKeyOrigin, err)
+	assert.Nil(t, origin)
+
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Nil(t, ma.KeyOrigin)
+
+	desc, err = wallet.ReceiveOutputDescriptor()
+	assert.Nil(t, err)
+	assert.NotContains(t, desc, "73c5da0a")
+
+	wallet.UpdateCoin(NewBaseCoin(49, 0, 0))
+	_, err = wallet.AccountKeyOrigin()
+	assert.Equal(t, ErrMissingKeyOrigin, err)
+
+	wallet.UpdateCoin(BaseCoinBip84MainNet)
+	origin, err = wallet.AccountKeyOrigin()
+	assert.Nil(t, err)
+	assert.Equal(t, "73c5da0a/84'/0'/0'", origin.String())
+}
@@ -0,0 +1,437 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// Message signatures prove ownership of an address. Legacy (P2PKH) and nested segwit (P2SH-P2WPKH) addresses are signed with
+// BIP137 compact signatures, the format exchanges and most wallets accept for those address types. Native segwit (P2WPKH)
+// and taproot (P2TR) addresses are signed with BIP322 "simple" signatures, the base64 encoded witness of a virtual
+// transaction spending the address. Both are base64 encoded, and VerifyMessageSignature tells them apart by shape.
+
+const (
+	bip137MagicMessage     = "Bitcoin Signed Message:\n"
+	bip137SignatureSize    = 65
+	bip137HeaderP2PKHUnc   = 27
+	bip137HeaderP2PKH      = 31
+	bip137HeaderP2SHP2WPKH = 35
+	bip137HeaderP2WPKH     = 39
+	bip137HeaderMax        = 42
+)
+
+var (
+	// ErrUnsupportedAddressType describes an error in which a message cannot be signed or verified for an address's type.
+	ErrUnsupportedAddressType = errors.New("unsupported address type for message signing")
+
+	// ErrAddressMismatch describes an error in which a MetaAddress's address does not match the key at its derivation path.
+	ErrAddressMismatch = errors.New("address does not match derivation path")
+
+	// ErrInvalidMessageSignature describes an error in which a message signature cannot be decoded.
+	ErrInvalidMessageSignature = errors.New("invalid message signature encoding")
+)
+
+/// Receiver methods
+
+// SignMessage signs a message with the key behind a receive or change MetaAddress of this wallet, returning a base64 encoded
+// signature. BIP44/BIP49 addresses produce BIP137 signatures; BIP84/BIP86 addresses produce BIP322 simple signatures.
+func (wallet *HDWallet) SignMessage(metaAddress *MetaAddress, message string) (string, error) {
+	privateKey, err := wallet.messageSigningKey(metaAddress)
+	if err != nil {
+		return "", err
+	}
+
+	switch metaAddress.DerivationPath.Purpose {
+	case bip44purpose:
+		return signMessageBIP137(privateKey, message, bip137HeaderP2PKH)
+	case bip49purpose:
+		return signMessageBIP137(privateKey, message, bip137HeaderP2SHP2WPKH)
+	case bip84purpose, bip86purpose:
+		return signMessageBIP322(privateKey, metaAddress.DerivationPath, message)
+	}
+	return "", ErrUnsupportedAddressType
+}
+
+// SignMessageBIP137 signs a message with the key behind a BIP44, BIP49 or BIP84 MetaAddress of this wallet, returning a base64
+// encoded BIP137 compact signature, for services which do not yet accept BIP322 signatures for native segwit addresses.
+func (wallet *HDWallet) SignMessageBIP137(metaAddress *MetaAddress, message string) (string, error) {
+	privateKey, err := wallet.messageSigningKey(metaAddress)
+	if err != nil {
+		return "", err
+	}
+
+	switch metaAddress.DerivationPath.Purpose {
+	case bip44purpose:
+		return signMessageBIP137(privateKey, message, bip137HeaderP2PKH)
+	case bip49purpose:
+		return signMessageBIP137(privateKey, message, bip137HeaderP2SHP2WPKH)
+	case bip84purpose:
+		return signMessageBIP137(privateKey, message, bip137HeaderP2WPKH)
+	}
+	return "", ErrUnsupportedAddressType
+}
+
+// messageSigningKey derives the private key for a MetaAddress, and checks it produces the MetaAddress's address.
+func (wallet *HDWallet) messageSigningKey(metaAddress *MetaAddress) (*btcec.PrivateKey, error) {
+	if metaAddress == nil || metaAddress.DerivationPath == nil {
+		return nil, errors.New("found nil derivation path")
+	}
+
+	ua, err := newUsableAddressWithDerivationPath(wallet, metaAddress.DerivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := generateAddress(metaAddress.DerivationPath, ua.derivedPrivateKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	if addr != metaAddress.Address {
+		return nil, ErrAddressMismatch
+	}
+
+	return ua.derivedPrivateKey, nil
+}
+
+/// Verification
+
+// VerifyMessageSignature checks a base64 encoded BIP137 or BIP322 simple signature of a message against an address.
+// It returns false with no error if the signature is well formed but does not prove ownership of the address.
+func VerifyMessageSignature(address string, message string, signature string) (bool, error) {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, ErrInvalidMessageSignature
+	}
+
+	if outputKey, _, err := decodeTaprootAddress(address); err == nil {
+		return verifyMessageBIP322Taproot(outputKey, message, sig)
+	}
+
+	addr, err := decodeAddressForAnyNetwork(address)
+	if err != nil {
+		return false, err
+	}
+
+	if len(sig) == bip137SignatureSize && sig[0] >= bip137HeaderP2PKHUnc && sig[0] <= bip137HeaderMax {
+		return verifyMessageBIP137(addr, message, sig)
+	}
+
+	if _, ok := addr.(*btcutil.AddressWitnessPubKeyHash); ok {
+		return verifyMessageBIP322WitnessPubKeyHash(addr, message, sig)
+	}
+
+	return false, ErrInvalidMessageSignature
+}
+
+/// BIP137
+
+// bip137MessageHash returns the double sha256 of the magic-prefixed message.
+func bip137MessageHash(message string) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, bip137MagicMessage)
+	_ = wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+func signMessageBIP137(privateKey *btcec.PrivateKey, message string, headerBase byte) (string, error) {
+	sig, err := btcec.SignCompact(btcec.S256(), privateKey, bip137MessageHash(message), true)
+	if err != nil {
+		return "", err
+	}
+
+	// SignCompact sets the header for a compressed P2PKH key (31-34); shift it into the requested address type's range.
+	sig[0] = sig[0] - bip137HeaderP2PKH + headerBase
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// verifyMessageBIP137 recovers the signing key, and checks that it produces the address. Like most wallets, the address
+// type is taken from the address rather than the header, since signers disagree on headers for segwit addresses.
+func verifyMessageBIP137(addr btcutil.Address, message string, sig []byte) (bool, error) {
+	header := sig[0]
+	compressed := header >= bip137HeaderP2PKH
+
+	// normalize the header to the compact signature form btcec expects, 27-30 uncompressed and 31-34 compressed.
+	compact := make([]byte, len(sig))
+	copy(compact, sig)
+	recoveryID := (header - bip137HeaderP2PKHUnc) % 4
+	compact[0] = bip137HeaderP2PKHUnc + recoveryID
+	if compressed {
+		compact[0] += 4
+	}
+
+	pubkey, wasCompressed, err := btcec.RecoverCompact(btcec.S256(), compact, bip137MessageHash(message))
+	if err != nil {
+		return false, nil
+	}
+
+	var serialized []byte
+	if wasCompressed {
+		serialized = pubkey.SerializeCompressed()
+	} else {
+		serialized = pubkey.SerializeUncompressed()
+	}
+	keyHash := btcutil.Hash160(serialized)
+
+	switch a := addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return bytes.Equal(a.Hash160()[:], keyHash), nil
+	case *btcutil.AddressScriptHash:
+		if !wasCompressed {
+			return false, nil
+		}
+		scriptSig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(a.Hash160()[:], btcutil.Hash160(scriptSig)), nil
+	case *btcutil.AddressWitnessPubKeyHash:
+		if !wasCompressed {
+			return false, nil
+		}
+		return bytes.Equal(a.WitnessProgram(), keyHash), nil
+	}
+	return false, ErrUnsupportedAddressType
+}
+
+/// BIP322
+
+// bip322MessageHash returns the tagged hash of the message committed to by the virtual to_spend transaction.
+func bip322MessageHash(message string) []byte {
+	return taggedHash("BIP0322-signed-message", []byte(message))
+}
+
+// bip322ToSpend builds the virtual transaction whose single output, paying to pkScript, is spent to prove ownership.
+func bip322ToSpend(pkScript []byte, message string) (*wire.MsgTx, error) {
+	scriptSig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(bip322MessageHash(message)).Script()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(0)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0xffffffff), scriptSig, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	return tx, nil
+}
+
+// bip322ToSign builds the virtual transaction spending to_spend, with the given witness.
+func bip322ToSign(toSpend *wire.MsgTx, witness wire.TxWitness) *wire.MsgTx {
+	toSpendHash := toSpend.TxHash()
+	tx := wire.NewMsgTx(0)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&toSpendHash, 0), nil, witness)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return tx
+}
+
+func signMessageBIP322(privateKey *btcec.PrivateKey, path *DerivationPath, message string) (string, error) {
+	var pkScript []byte
+	if path.Purpose == bip86purpose {
+		outputKey, err := taprootOutputKey(privateKey.PubKey())
+		if err != nil {
+			return "", err
+		}
+		pkScript = taprootScript(outputKey)
+	} else {
+		keyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(keyHash, path.BaseCoin.defaultNetParams())
+		if err != nil {
+			return "", err
+		}
+		pkScript, err = txscript.PayToAddrScript(addr)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return "", err
+	}
+	toSign := bip322ToSign(toSpend, nil)
+
+	var witness wire.TxWitness
+	if path.Purpose == bip86purpose {
+		aux, err := randBytes(32)
+		if err != nil {
+			return "", err
+		}
+		outputKey, err := taprootOutputPrivateKey(privateKey)
+		if err != nil {
+			return "", err
+		}
+		sig, err := schnorrSign(outputKey, taprootSigHash(toSign, pkScript), aux)
+		if err != nil {
+			return "", err
+		}
+		witness = wire.TxWitness{sig}
+	} else {
+		sigHashes := txscript.NewTxSigHashes(toSign)
+		witness, err = txscript.WitnessSignature(toSign, sigHashes, 0, 0, pkScript, txscript.SigHashAll, privateKey, true)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	encoded, err := serializeWitness(witness)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+func verifyMessageBIP322WitnessPubKeyHash(addr btcutil.Address, message string, sig []byte) (bool, error) {
+	witness, err := deserializeWitness(sig)
+	if err != nil {
+		return false, err
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return false, err
+	}
+
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return false, err
+	}
+	toSign := bip322ToSign(toSpend, witness)
+
+	vm, err := txscript.NewEngine(pkScript, toSign, 0, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(toSign), 0)
+	if err != nil {
+		return false, nil
+	}
+	return vm.Execute() == nil, nil
+}
+
+func verifyMessageBIP322Taproot(outputKey []byte, message string, sig []byte) (bool, error) {
+	witness, err := deserializeWitness(sig)
+	if err != nil {
+		return false, err
+	}
+	if len(witness) != 1 {
+		return false, nil
+	}
+
+	// a 65 byte signature carries an explicit sighash type, which must not be SIGHASH_DEFAULT
+	schnorrSig := witness[0]
+	if len(schnorrSig) == schnorrSignatureSize+1 {
+		if schnorrSig[schnorrSignatureSize] != byte(txscript.SigHashAll) {
+			return false, nil
+		}
+	} else if len(schnorrSig) != schnorrSignatureSize {
+		return false, nil
+	}
+
+	pkScript := taprootScript(outputKey)
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return false, err
+	}
+	toSign := bip322ToSign(toSpend, witness)
+
+	hashType := byte(0)
+	if len(schnorrSig) == schnorrSignatureSize+1 {
+		hashType = schnorrSig[schnorrSignatureSize]
+	}
+	return schnorrVerify(outputKey, taprootSigHashWithType(toSign, pkScript, hashType), schnorrSig[:schnorrSignatureSize]), nil
+}
+
+// taprootSigHash computes the BIP341 key path signature hash (SIGHASH_DEFAULT) for the single input of a BIP322 to_sign transaction.
+func taprootSigHash(tx *wire.MsgTx, prevPkScript []byte) []byte {
+	return taprootSigHashWithType(tx, prevPkScript, 0)
+}
+
+// taprootSigHashWithType computes the BIP341 key path signature hash for input 0, spending a zero value output, for
+// SIGHASH_DEFAULT or SIGHASH_ALL, the only types BIP322 simple signatures use.
+func taprootSigHashWithType(tx *wire.MsgTx, prevPkScript []byte, hashType byte) []byte {
+	var prevouts, amounts, scriptPubKeys, sequences, outputs bytes.Buffer
+	for _, txIn := range tx.TxIn {
+		prevouts.Write(txIn.PreviousOutPoint.Hash[:])
+		_ = binary.Write(&prevouts, binary.LittleEndian, txIn.PreviousOutPoint.Index)
+		_ = binary.Write(&amounts, binary.LittleEndian, int64(0))
+		_ = wire.WriteVarBytes(&scriptPubKeys, 0, prevPkScript)
+		_ = binary.Write(&sequences, binary.LittleEndian, txIn.Sequence)
+	}
+	for _, txOut := range tx.TxOut {
+		_ = wire.WriteTxOut(&outputs, 0, 0, txOut)
+	}
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // epoch
+	msg.WriteByte(hashType)
+	_ = binary.Write(&msg, binary.LittleEndian, tx.Version)
+	_ = binary.Write(&msg, binary.LittleEndian, tx.LockTime)
+	for _, part := range [][]byte{prevouts.Bytes(), amounts.Bytes(), scriptPubKeys.Bytes(), sequences.Bytes(), outputs.Bytes()} {
+		sum := sha256.Sum256(part)
+		msg.Write(sum[:])
+	}
+	msg.WriteByte(0x00) // spend type: key path, no annex
+	_ = binary.Write(&msg, binary.LittleEndian, uint32(0))
+
+	return taggedHash("TapSighash", msg.Bytes())
+}
+
+func serializeWitness(witness wire.TxWitness) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return nil, err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func deserializeWitness(data []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(data)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil || count > uint64(len(data)) {
+		return nil, ErrInvalidMessageSignature
+	}
+	witness := make(wire.TxWitness, 0, count)
+	for i := uint64(0); i < count; i++ {
+		item, err := wire.ReadVarBytes(r, 0, uint32(len(data)), "witness item")
+		if err != nil {
+			return nil, ErrInvalidMessageSignature
+		}
+		witness = append(witness, item)
+	}
+	if r.Len() != 0 {
+		return nil, ErrInvalidMessageSignature
+	}
+	return witness, nil
+}
+
+// decodeAddressForAnyNetwork decodes a base58check or bech32 address for mainnet, testnet or regtest.
+func decodeAddressForAnyNetwork(address string) (btcutil.Address, error) {
+	params := &chaincfg.MainNetParams
+	if sep := strings.LastIndexByte(address, '1'); sep > 0 {
+		if segwitParams := netParamsForSegwitHRP(strings.ToLower(address[:sep])); segwitParams != nil {
+			return btcutil.DecodeAddress(address, segwitParams)
+		}
+	}
+	// base58 addresses only decode against the params passed in, so pick them from the version byte
+	if _, netID, err := base58.CheckDecode(address); err == nil {
+		if netID == chaincfg.TestNet3Params.PubKeyHashAddrID || netID == chaincfg.TestNet3Params.ScriptHashAddrID {
+			params = &chaincfg.TestNet3Params
+		}
+	}
+	return btcutil.DecodeAddress(address, params)
+}
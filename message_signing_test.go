@@ -0,0 +1,160 @@
+package cnlib
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bip322TestAddress = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	bip322TestKey     = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+)
+
+func TestVerifyMessageSignature_BIP322Vectors(t *testing.T) {
+	valid, err := VerifyMessageSignature(bip322TestAddress, "", "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=")
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, err = VerifyMessageSignature(bip322TestAddress, "Hello World", "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=")
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	// signature for "" does not verify "Hello World"
+	valid, err = VerifyMessageSignature(bip322TestAddress, "Hello World", "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=")
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
+
+func TestSignMessageBIP322_VectorKey(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(bip322TestKey)
+	assert.Nil(t, err)
+	path := NewDerivationPath(BaseCoinBip84MainNet, 0, 0)
+
+	// Bitcoin Core grinds for a low R value, so only the verification result is compared with the vectors.
+	sig, err := signMessageBIP322(wif.PrivKey, path, "Hello World")
+	assert.Nil(t, err)
+
+	valid, err := VerifyMessageSignature(bip322TestAddress, "Hello World", sig)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+}
+
+func TestSignMessage_AllPurposes(t *testing.T) {
+	message := "I own this address"
+
+	for _, purpose := range []int{44, 49, 84, 86} {
+		bc := NewBaseCoin(purpose, 0, 0)
+		wallet := NewHDWalletFromWords(w, bc)
+		ma, err := wallet.MessageSigningAddressForIndex(0, 2)
+		assert.Nil(t, err)
+
+		sig, err := wallet.SignMessage(ma, message)
+		assert.Nil(t, err)
+
+		valid, err := VerifyMessageSignature(ma.Address, message, sig)
+		assert.Nil(t, err)
+		assert.Truef(t, valid, "purpose %v", purpose)
+
+		valid, err = VerifyMessageSignature(ma.Address, message+".", sig)
+		assert.Nil(t, err)
+		assert.Falsef(t, valid, "purpose %v", purpose)
+
+		other, err := wallet.MessageSigningAddressForIndex(0, 3)
+		assert.Nil(t, err)
+		valid, _ = VerifyMessageSignature(other.Address, message, sig)
+		assert.Falsef(t, valid, "purpose %v", purpose)
+	}
+}
+
+func TestSignMessage_Testnet(t *testing.T) {
+	for _, purpose := range []int{44, 49, 84, 86} {
+		wallet := NewHDWalletFromWords(w, NewBaseCoin(purpose, 1, 0))
+		ma, err := wallet.MessageSigningAddressForIndex(1, 0)
+		assert.Nil(t, err)
+
+		sig, err := wallet.SignMessage(ma, "testnet")
+		assert.Nil(t, err)
+
+		valid, err := VerifyMessageSignature(ma.Address, "testnet", sig)
+		assert.Nil(t, err)
+		assert.Truef(t, valid, "purpose %v", purpose)
+	}
+}
+
+func TestSignMessageBIP137_Headers(t *testing.T) {
+	expected := map[int][]byte{44: {31, 34}, 49: {35, 38}, 84: {39, 42}}
+
+	for purpose, headerRange := range expected {
+		bc := NewBaseCoin(purpose, 0, 0)
+		wallet := NewHDWalletFromWords(w, bc)
+		ma, err := wallet.ReceiveAddressForIndex(0)
+		assert.Nil(t, err)
+
+		sig, err := wallet.SignMessageBIP137(ma, "Hello World")
+		assert.Nil(t, err)
+
+		raw, err := base64.StdEncoding.DecodeString(sig)
+		assert.Nil(t, err)
+		assert.Equal(t, 65, len(raw))
+		assert.True(t, raw[0] >= headerRange[0] && raw[0] <= headerRange[1])
+
+		valid, err := VerifyMessageSignature(ma.Address, "Hello World", sig)
+		assert.Nil(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestVerifyMessageSignature_BIP137ElectrumStyleHeader(t *testing.T) {
+	// Electrum signs segwit addresses with compressed P2PKH headers (31-34); the address decides the type.
+	wallet := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	key, err := wallet.messageSigningKey(ma)
+	assert.Nil(t, err)
+
+	sig, err := signMessageBIP137(key, "Hello World", bip137HeaderP2PKH)
+	assert.Nil(t, err)
+
+	valid, err := VerifyMessageSignature(ma.Address, "Hello World", sig)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+}
+
+func TestSignMessageBIP137_Taproot(t *testing.T) {
+	bc := NewBaseCoin(86, 0, 0)
+	wallet := NewHDWalletFromWords(w, bc)
+	ma, err := wallet.MessageSigningAddressForIndex(0, 0)
+	assert.Nil(t, err)
+
+	sig, err := wallet.SignMessageBIP137(ma, "Hello World")
+	assert.Equal(t, ErrUnsupportedAddressType, err)
+	assert.Equal(t, "", sig)
+}
+
+func TestSignMessage_AddressMismatch(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+
+	forged := NewMetaAddress("bc1qd30z5a5e50jtgx28rvt64483tq65r9pkj623wh", ma.DerivationPath, "")
+	sig, err := wallet.SignMessage(forged, "Hello World")
+	assert.Equal(t, ErrAddressMismatch, err)
+	assert.Equal(t, "", sig)
+}
+
+func TestVerifyMessageSignature_Malformed(t *testing.T) {
+	valid, err := VerifyMessageSignature(bip322TestAddress, "Hello World", "not base64!")
+	assert.Equal(t, ErrInvalidMessageSignature, err)
+	assert.False(t, valid)
+
+	valid, err = VerifyMessageSignature(bip322TestAddress, "Hello World", base64.StdEncoding.EncodeToString([]byte{0x05, 0x01}))
+	assert.Equal(t, ErrInvalidMessageSignature, err)
+	assert.False(t, valid)
+
+	valid, err = VerifyMessageSignature("not an address", "Hello World", "AA==")
+	assert.NotNil(t, err)
+	assert.False(t, valid)
+}
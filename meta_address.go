@@ -7,6 +7,12 @@ type MetaAddress struct {
 	Address               string
 	DerivationPath        *DerivationPath
 	UncompressedPublicKey string
+	KeyOrigin             *KeyOrigin // master key fingerprint and full path, when known
+}
+
+// MetaAddressList is a gomobile-friendly list of MetaAddresses.
+type MetaAddressList struct {
+	addresses []*MetaAddress
 }
 
 /// Constructors
@@ -22,3 +28,16 @@ func (ma *MetaAddress) IsReceiveAddress() bool {
 	change := ma.DerivationPath.Change
 	return change == 0
 }
+
+// Count returns the number of addresses in the list.
+func (l *MetaAddressList) Count() int {
+	return len(l.addresses)
+}
+
+// MetaAddressAtIndex returns the MetaAddress at index i, or nil if out of range.
+func (l *MetaAddressList) MetaAddressAtIndex(i int) *MetaAddress {
+	if i < 0 || i >= len(l.addresses) {
+		return nil
+	}
+	return l.addresses[i]
+}
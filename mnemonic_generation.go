@@ -0,0 +1,123 @@
+package cnlib
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+const (
+	maxEntropyPatternLength = 4
+)
+
+// entropyReader is the source of system entropy, replaceable in tests.
+var entropyReader io.Reader = rand.Reader
+
+var (
+	// ErrInvalidWordCount describes an error in which the caller asked for a mnemonic length other than 12, 15, 18, 21 or 24 words.
+	ErrInvalidWordCount = errors.New("mnemonic word count must be 12, 15, 18, 21 or 24")
+
+	// ErrWeakEntropy describes an error in which entropy failed a sanity check, e.g. all zeros or a repeating pattern.
+	ErrWeakEntropy = errors.New("entropy failed sanity checks")
+)
+
+/// Type Definition
+
+// MnemonicOptions configures mnemonic generation. UserEntropy, such as dice rolls or coin flips, is optional and is mixed
+// into system entropy, never used in place of it.
+type MnemonicOptions struct {
+	WordCount   int
+	Language    string
+	UserEntropy string
+}
+
+/// Constructors
+
+// NewMnemonicOptions returns a pointer to MnemonicOptions for an English mnemonic of wordCount words.
+func NewMnemonicOptions(wordCount int) *MnemonicOptions {
+	return &MnemonicOptions{WordCount: wordCount, Language: BIP39LanguageEnglish}
+}
+
+/// Package functions
+
+// NewMnemonic returns a new English mnemonic of wordCount words, drawn from crypto/rand.
+func NewMnemonic(wordCount int) (string, error) {
+	return NewMnemonicWithOptions(NewMnemonicOptions(wordCount))
+}
+
+// NewMnemonicWithOptions returns a new mnemonic drawn from crypto/rand, with any user entropy mixed in.
+func NewMnemonicWithOptions(options *MnemonicOptions) (string, error) {
+	if options == nil {
+		return "", errors.New("no mnemonic options provided")
+	}
+	size, err := entropySizeForWordCount(options.WordCount)
+	if err != nil {
+		return "", err
+	}
+	language := options.Language
+	if language == "" {
+		language = BIP39LanguageEnglish
+	}
+
+	systemEntropy := make([]byte, size)
+	if _, err := io.ReadFull(entropyReader, systemEntropy); err != nil {
+		return "", err
+	}
+	if err := checkEntropy(systemEntropy); err != nil {
+		return "", err
+	}
+
+	entropy := systemEntropy
+	if options.UserEntropy != "" {
+		entropy = mixEntropy(systemEntropy, []byte(options.UserEntropy))
+		if err := checkEntropy(entropy); err != nil {
+			return "", err
+		}
+	}
+
+	return NewWordListFromEntropyForLanguage(entropy, language)
+}
+
+/// Unexported functions
+
+func entropySizeForWordCount(wordCount int) (int, error) {
+	switch wordCount {
+	case 12, 15, 18, 21, 24:
+		return wordCount * 11 * 32 / 33 / 8, nil
+	}
+	return 0, ErrInvalidWordCount
+}
+
+// mixEntropy hashes user entropy into system entropy, so the result is no weaker than the system entropy alone.
+func mixEntropy(systemEntropy []byte, userEntropy []byte) []byte {
+	userHash := sha256.Sum256(userEntropy)
+	mixed := sha256.Sum256(append(append([]byte{}, systemEntropy...), userHash[:]...))
+	return mixed[:len(systemEntropy)]
+}
+
+// checkEntropy rejects entropy that is obviously broken: a short repeating pattern, or fewer distinct bytes than half
+// its length, which uniformly random entropy of 16 to 32 bytes practically never has.
+func checkEntropy(entropy []byte) error {
+	distinct := make(map[byte]bool)
+	for _, b := range entropy {
+		distinct[b] = true
+	}
+	if len(distinct) < len(entropy)/2 {
+		return ErrWeakEntropy
+	}
+
+	for period := 1; period <= maxEntropyPatternLength; period++ {
+		repeating := true
+		for i := period; i < len(entropy); i++ {
+			if entropy[i] != entropy[i-period] {
+				repeating = false
+				break
+			}
+		}
+		if repeating {
+			return ErrWeakEntropy
+		}
+	}
+	return nil
+}
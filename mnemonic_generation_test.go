@@ -0,0 +1,110 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withEntropyReader(entropy []byte) func() {
+	entropyReader = bytes.NewReader(entropy)
+	return func() { entropyReader = rand.Reader }
+}
+
+func TestNewMnemonic_WordCounts(t *testing.T) {
+	for _, count := range []int{12, 15, 18, 21, 24} {
+		words, err := NewMnemonic(count)
+		assert.Nil(t, err)
+		assert.Equal(t, count, len(strings.Fields(words)))
+
+		language, err := DetectMnemonicLanguage(words)
+		assert.Nil(t, err)
+		assert.Equal(t, BIP39LanguageEnglish, language)
+	}
+}
+
+func TestNewMnemonic_InvalidWordCount(t *testing.T) {
+	for _, count := range []int{0, 11, 13, 25} {
+		words, err := NewMnemonic(count)
+		assert.Equal(t, ErrInvalidWordCount, err)
+		assert.Equal(t, "", words)
+	}
+}
+
+func TestNewMnemonic_UsesEntropyReader(t *testing.T) {
+	entropy := []byte{
+		0x9e, 0x88, 0x5d, 0x95, 0x2a, 0xd3, 0x62, 0xca, 0xeb, 0x4e, 0xfe, 0x34, 0xa8, 0xe9, 0x1b, 0xd2,
+	}
+	defer withEntropyReader(entropy)()
+
+	words, err := NewMnemonic(12)
+	assert.Nil(t, err)
+	expected, _ := NewWordListFromEntropy(entropy)
+	assert.Equal(t, expected, words)
+}
+
+func TestNewMnemonicWithOptions_MixesUserEntropy(t *testing.T) {
+	entropy := []byte{
+		0x9e, 0x88, 0x5d, 0x95, 0x2a, 0xd3, 0x62, 0xca, 0xeb, 0x4e, 0xfe, 0x34, 0xa8, 0xe9, 0x1b, 0xd2,
+	}
+	plain, _ := NewWordListFromEntropy(entropy)
+
+	options := NewMnemonicOptions(12)
+	options.UserEntropy = "6 2 4 1 1 5 3 6 2 2 4 5 1 6 3 3 2 5 4 1"
+
+	defer withEntropyReader(entropy)()
+	first, err := NewMnemonicWithOptions(options)
+	assert.Nil(t, err)
+	assert.NotEqual(t, plain, first)
+
+	withEntropyReader(entropy)
+	second, err := NewMnemonicWithOptions(options)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+
+	options.UserEntropy = "HTTHHTHTTTHHTHTH"
+	withEntropyReader(entropy)
+	third, err := NewMnemonicWithOptions(options)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, third)
+}
+
+func TestNewMnemonicWithOptions_Language(t *testing.T) {
+	options := NewMnemonicOptions(24)
+	options.Language = BIP39LanguageJapanese
+
+	words, err := NewMnemonicWithOptions(options)
+	assert.Nil(t, err)
+
+	language, err := DetectMnemonicLanguage(words)
+	assert.Nil(t, err)
+	assert.Equal(t, BIP39LanguageJapanese, language)
+
+	options.Language = "klingon"
+	_, err = NewMnemonicWithOptions(options)
+	assert.Equal(t, ErrUnsupportedMnemonicLanguage, err)
+}
+
+func TestNewMnemonic_RejectsWeakEntropy(t *testing.T) {
+	for _, entropy := range [][]byte{
+		make([]byte, 16),
+		bytes.Repeat([]byte{0xff}, 16),
+		bytes.Repeat([]byte{0x01, 0x02}, 8),
+		bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 4),
+		bytes.Repeat([]byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x00}, 2),
+	} {
+		restore := withEntropyReader(entropy)
+		_, err := NewMnemonic(12)
+		restore()
+		assert.Equal(t, ErrWeakEntropy, err)
+	}
+}
+
+func TestNewMnemonic_ShortRead(t *testing.T) {
+	defer withEntropyReader([]byte{0x01, 0x02})()
+	_, err := NewMnemonic(12)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,205 @@
+package cnlib
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// BIP39 wordlist languages.
+const (
+	BIP39LanguageEnglish            = "english"
+	BIP39LanguageSpanish            = "spanish"
+	BIP39LanguageFrench             = "french"
+	BIP39LanguageItalian            = "italian"
+	BIP39LanguageJapanese           = "japanese"
+	BIP39LanguageKorean             = "korean"
+	BIP39LanguageChineseSimplified  = "chinese_simplified"
+	BIP39LanguageChineseTraditional = "chinese_traditional"
+
+	bip39SeedIterations   = 2048
+	bip39SeedSize         = 64
+	japaneseWordSeparator = "　" // ideographic space
+)
+
+// bip39Languages lists the wordlists in the order language detection tries them.
+var bip39Languages = []string{
+	BIP39LanguageEnglish,
+	BIP39LanguageSpanish,
+	BIP39LanguageFrench,
+	BIP39LanguageItalian,
+	BIP39LanguageJapanese,
+	BIP39LanguageKorean,
+	BIP39LanguageChineseSimplified,
+	BIP39LanguageChineseTraditional,
+}
+
+var bip39Wordlists = map[string][]string{
+	BIP39LanguageEnglish:            wordlists.English,
+	BIP39LanguageSpanish:            wordlists.Spanish,
+	BIP39LanguageFrench:             wordlists.French,
+	BIP39LanguageItalian:            wordlists.Italian,
+	BIP39LanguageJapanese:           wordlists.Japanese,
+	BIP39LanguageKorean:             wordlists.Korean,
+	BIP39LanguageChineseSimplified:  wordlists.ChineseSimplified,
+	BIP39LanguageChineseTraditional: wordlists.ChineseTraditional,
+}
+
+var (
+	bip39IndexesOnce sync.Once
+	bip39Indexes     map[string]map[string]int // NFKD normalized word to index, per language
+)
+
+var (
+	// ErrUnsupportedMnemonicLanguage describes an error in which the caller passed a wordlist language that is not supported.
+	ErrUnsupportedMnemonicLanguage = errors.New("unsupported mnemonic language")
+
+	// ErrInvalidMnemonic describes an error in which a mnemonic is not a valid BIP39 mnemonic in any supported language.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+)
+
+/// Package functions
+
+// GetFullBIP39WordListStringForLanguage returns all 2,048 BIP39 mnemonic words of a language as a space-separated string.
+func GetFullBIP39WordListStringForLanguage(language string) (string, error) {
+	list, ok := bip39Wordlists[language]
+	if !ok {
+		return "", ErrUnsupportedMnemonicLanguage
+	}
+	return strings.Join(list, " "), nil
+}
+
+// NewWordListFromEntropyForLanguage returns a mnemonic in the given language from entropy. Japanese words are separated
+// by ideographic spaces, every other language by spaces.
+func NewWordListFromEntropyForLanguage(entropy []byte, language string) (string, error) {
+	list, ok := bip39Wordlists[language]
+	if !ok {
+		return "", ErrUnsupportedMnemonicLanguage
+	}
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", errors.New("entropy must be 128 to 256 bits, in multiples of 32 bits")
+	}
+
+	checksumBits := uint(len(entropy) * 8 / 32)
+	hash := sha256.Sum256(entropy)
+
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	wordCount := (len(entropy)*8 + int(checksumBits)) / 11
+	words := make([]string, wordCount)
+	mask := big.NewInt(2047)
+	for i := wordCount - 1; i >= 0; i-- {
+		words[i] = list[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+
+	separator := " "
+	if language == BIP39LanguageJapanese {
+		separator = japaneseWordSeparator
+	}
+	return strings.Join(words, separator), nil
+}
+
+// DetectMnemonicLanguage returns the wordlist language of a mnemonic, and validates its words and checksum. Returns
+// ErrInvalidMnemonic if it is not valid in any language. The Chinese lists share 1,275 words at the same indexes, so a
+// mnemonic made only of shared words is reported as simplified; it decodes to the same entropy and seed either way.
+func DetectMnemonicLanguage(wordString string) (string, error) {
+	return detectMnemonicLanguage(mnemonicWords(wordString))
+}
+
+/// Unexported functions
+
+// mnemonicWords returns the NFKD normalized, lower-cased words of a mnemonic, for wordlist lookups only; seeds are
+// derived from the mnemonic as given. Any run of whitespace separates words, including the ideographic space, which
+// NFKD maps to an ASCII space. Every wordlist is lower case, and only the Latin-script ones have case at all, so
+// lower-casing accepts capitalized input without affecting the others.
+func mnemonicWords(wordString string) []string {
+	return strings.Fields(strings.ToLower(norm.NFKD.String(wordString)))
+}
+
+// detectMnemonicLanguage returns the first language in which normalized mnemonic words are valid.
+func detectMnemonicLanguage(words []string) (string, error) {
+	for _, language := range bip39Languages {
+		if _, err := mnemonicEntropy(words, language); err == nil {
+			return language, nil
+		}
+	}
+	return "", ErrInvalidMnemonic
+}
+
+// mnemonicEntropy returns the entropy of NFKD normalized mnemonic words, checking them against a language's wordlist.
+func mnemonicEntropy(words []string, language string) ([]byte, error) {
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, ErrInvalidMnemonic
+	}
+
+	indexes := bip39WordIndexes()[language]
+	if indexes == nil {
+		return nil, ErrUnsupportedMnemonicLanguage
+	}
+
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := indexes[word]
+		if !ok {
+			return nil, ErrInvalidMnemonic
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+
+	checksumBits := uint(len(words) * 11 / 33)
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<checksumBits-1)))
+	data.Rsh(data, checksumBits)
+
+	entropy := make([]byte, len(words)*11*32/33/8)
+	dataBytes := data.Bytes()
+	copy(entropy[len(entropy)-len(dataBytes):], dataBytes)
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum.Int64() {
+		return nil, ErrInvalidMnemonic
+	}
+	return entropy, nil
+}
+
+// mnemonicSeed returns the BIP39 seed of a mnemonic in any supported language, after checking its words and checksum.
+// The seed comes from the mnemonic as given rather than its normalized words, as BIP39 specifies.
+func mnemonicSeed(wordString string, passphrase string) ([]byte, error) {
+	if _, err := DetectMnemonicLanguage(wordString); err != nil {
+		return nil, err
+	}
+	return bip39Seed(wordString, passphrase), nil
+}
+
+// bip39Seed returns the BIP39 seed for a mnemonic and passphrase, both NFKD normalized first.
+func bip39Seed(wordString string, passphrase string) []byte {
+	mnemonic := norm.NFKD.Bytes([]byte(wordString))
+	salt := norm.NFKD.Bytes([]byte("mnemonic" + passphrase))
+	return pbkdf2.Key(mnemonic, salt, bip39SeedIterations, bip39SeedSize, sha512.New)
+}
+
+func bip39WordIndexes() map[string]map[string]int {
+	bip39IndexesOnce.Do(func() {
+		bip39Indexes = make(map[string]map[string]int, len(bip39Wordlists))
+		for language, list := range bip39Wordlists {
+			indexes := make(map[string]int, len(list))
+			for i, word := range list {
+				indexes[norm.NFKD.String(word)] = i
+			}
+			bip39Indexes[language] = indexes
+		}
+	})
+	return bip39Indexes
+}
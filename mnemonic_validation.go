@@ -0,0 +1,211 @@
+package cnlib
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	maxWordSuggestions     = 5
+	maxSuggestionDistance  = 2
+	uniqueWordPrefixLength = 4 // BIP39 wordlists are unique in their first four letters
+)
+
+/// Type Definition
+
+// MnemonicValidation reports why a mnemonic is or is not valid, for restore screens.
+type MnemonicValidation struct {
+	Language            string
+	WordCount           int
+	IsValid             bool
+	IsWordCountValid    bool
+	IsChecksumValid     bool
+	ChecksumOnlyFailure bool // every word is known and the count is valid, but the checksum fails
+	UnknownWordCount    int
+	ChecksumCandidates  string // space-separated words that satisfy the checksum, when exactly one word is unknown or the final word is missing
+	words               []*MnemonicWordValidation
+}
+
+// MnemonicWordValidation reports on one word of a mnemonic.
+type MnemonicWordValidation struct {
+	Index       int
+	Word        string
+	IsKnown     bool
+	Suggestions string // space-separated closest valid words, for unknown words
+}
+
+/// Package functions
+
+// ValidateMnemonic validates a mnemonic word by word. Pass an empty language to use the language the most words belong
+// to. Returns ErrUnsupportedMnemonicLanguage for an unknown language, otherwise the result describes any problem found.
+func ValidateMnemonic(wordString string, language string) (*MnemonicValidation, error) {
+	words := mnemonicWords(wordString)
+
+	if language == "" {
+		language = likelyMnemonicLanguage(words)
+	}
+	indexes, ok := bip39WordIndexes()[language]
+	if !ok {
+		return nil, ErrUnsupportedMnemonicLanguage
+	}
+
+	validation := &MnemonicValidation{Language: language, WordCount: len(words), IsWordCountValid: isValidMnemonicLength(len(words))}
+
+	unknownIndex := -1
+	for i, word := range words {
+		_, known := indexes[word]
+		wordValidation := &MnemonicWordValidation{Index: i, Word: norm.NFC.String(word), IsKnown: known}
+		if !known {
+			validation.UnknownWordCount++
+			unknownIndex = i
+			wordValidation.Suggestions = strings.Join(wordSuggestions(word, language), " ")
+		}
+		validation.words = append(validation.words, wordValidation)
+	}
+
+	if validation.IsWordCountValid && validation.UnknownWordCount == 0 {
+		_, err := mnemonicEntropy(words, language)
+		validation.IsChecksumValid = err == nil
+		validation.ChecksumOnlyFailure = err != nil
+	}
+	validation.IsValid = validation.IsChecksumValid
+
+	if validation.IsWordCountValid && validation.UnknownWordCount == 1 {
+		validation.ChecksumCandidates = strings.Join(checksumCandidates(words, unknownIndex, language), " ")
+	}
+
+	// one word short of a valid length, with every word known, is most likely a missing final word
+	if !validation.IsWordCountValid && validation.UnknownWordCount == 0 && isValidMnemonicLength(len(words)+1) {
+		validation.ChecksumCandidates = strings.Join(checksumCandidates(append(words, ""), len(words), language), " ")
+	}
+
+	return validation, nil
+}
+
+/// Receiver methods
+
+// WordValidationCount returns the number of words validated.
+func (v *MnemonicValidation) WordValidationCount() int {
+	return len(v.words)
+}
+
+// WordValidationAtIndex returns the validation of the word at index i, or nil if out of range.
+func (v *MnemonicValidation) WordValidationAtIndex(i int) *MnemonicWordValidation {
+	if i < 0 || i >= len(v.words) {
+		return nil
+	}
+	return v.words[i]
+}
+
+/// Unexported functions
+
+func isValidMnemonicLength(count int) bool {
+	switch count {
+	case 12, 15, 18, 21, 24:
+		return true
+	}
+	return false
+}
+
+// checksumCandidates returns the wordlist words that complete a valid mnemonic when placed at index.
+func checksumCandidates(words []string, index int, language string) []string {
+	candidates := []string{}
+	trial := append([]string{}, words...)
+	for _, candidate := range bip39Wordlists[language] {
+		trial[index] = norm.NFKD.String(candidate)
+		if _, err := mnemonicEntropy(trial, language); err == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// likelyMnemonicLanguage returns the language most of the words belong to, preferring earlier languages on a tie.
+func likelyMnemonicLanguage(words []string) string {
+	best, bestCount := BIP39LanguageEnglish, -1
+	for _, language := range bip39Languages {
+		indexes := bip39WordIndexes()[language]
+		count := 0
+		for _, word := range words {
+			if _, ok := indexes[word]; ok {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = language, count
+		}
+	}
+	return best
+}
+
+// wordSuggestions returns the closest wordlist words to an NFKD normalized word: words it is a prefix of, or that share
+// its unique prefix, first, then words within a small edit distance.
+func wordSuggestions(word string, language string) []string {
+	type suggestion struct {
+		word     string
+		rank     int
+		listSlot int
+	}
+
+	prefix := word
+	if utf8.RuneCountInString(word) > uniqueWordPrefixLength {
+		prefix = string([]rune(word)[:uniqueWordPrefixLength])
+	}
+
+	var suggestions []suggestion
+	for i, candidate := range bip39Wordlists[language] {
+		normalized := norm.NFKD.String(candidate)
+		if strings.HasPrefix(normalized, prefix) {
+			suggestions = append(suggestions, suggestion{word: candidate, rank: 0, listSlot: i})
+			continue
+		}
+		if distance := editDistance(word, normalized); distance <= maxSuggestionDistance {
+			suggestions = append(suggestions, suggestion{word: candidate, rank: distance, listSlot: i})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].rank != suggestions[j].rank {
+			return suggestions[i].rank < suggestions[j].rank
+		}
+		return suggestions[i].listSlot < suggestions[j].listSlot
+	})
+
+	words := []string{}
+	for i := 0; i < len(suggestions) && i < maxWordSuggestions; i++ {
+		words = append(words, suggestions[i].word)
+	}
+	return words
+}
+
+// editDistance returns the Levenshtein distance between two strings, by rune.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
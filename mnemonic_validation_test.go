@@ -0,0 +1,175 @@
+package cnlib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMnemonic_Valid(t *testing.T) {
+	validation, err := ValidateMnemonic(w, "")
+	assert.Nil(t, err)
+	assert.True(t, validation.IsValid)
+	assert.True(t, validation.IsWordCountValid)
+	assert.True(t, validation.IsChecksumValid)
+	assert.False(t, validation.ChecksumOnlyFailure)
+	assert.Equal(t, BIP39LanguageEnglish, validation.Language)
+	assert.Equal(t, 12, validation.WordValidationCount())
+	assert.True(t, validation.WordValidationAtIndex(11).IsKnown)
+	assert.Nil(t, validation.WordValidationAtIndex(12))
+}
+
+func TestValidateMnemonic_MixedCase(t *testing.T) {
+	validation, err := ValidateMnemonic(strings.Title(w), "")
+	assert.Nil(t, err)
+	assert.True(t, validation.IsValid)
+	assert.Equal(t, BIP39LanguageEnglish, validation.Language)
+	assert.Equal(t, "abandon", validation.WordValidationAtIndex(0).Word)
+
+	validation, err = ValidateMnemonic(strings.ToUpper(w), BIP39LanguageEnglish)
+	assert.Nil(t, err)
+	assert.True(t, validation.IsValid)
+}
+
+func TestDetectMnemonicLanguage_MixedCase(t *testing.T) {
+	entropy := []byte("mixed case words")
+	for _, language := range []string{BIP39LanguageEnglish, BIP39LanguageSpanish, BIP39LanguageFrench, BIP39LanguageItalian} {
+		words, err := NewWordListFromEntropyForLanguage(entropy, language)
+		assert.Nil(t, err)
+
+		detected, err := DetectMnemonicLanguage(strings.ToUpper(words))
+		assert.Nil(t, err)
+		assert.Equal(t, language, detected)
+	}
+
+	// detection is case insensitive, but the seed still comes from the words as given
+	lower := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	upper, err := NewHDWalletFromMnemonic(strings.ToUpper(w), "", BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+	assert.NotEqual(t, lower.masterPrivateKey.String(), upper.masterPrivateKey.String())
+}
+
+func TestNewHDWalletFromWords_MixedCaseSeed(t *testing.T) {
+	// a capitalized first word, as phone keyboards produce, derives the same keys it always has
+	words := "Abandon" + strings.TrimPrefix(w, "abandon")
+	xprv := "xprv9s21ZrQH143K4H7WQKFmsaPtGJvWaRY6bARFN3wJVBUYFDB92MyjQE9XAuZfHGggUACX24rJX9yQ1FZm7SNirzSs8AMwGTbaMU61bDWnyQB"
+
+	assert.Equal(t, xprv, NewHDWalletFromWords(words, BaseCoinBip84MainNet).masterPrivateKey.String())
+
+	wallet, err := NewHDWalletFromMnemonic(words, "", BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+	assert.Equal(t, xprv, wallet.masterPrivateKey.String())
+}
+
+func TestValidateMnemonic_UnknownWord(t *testing.T) {
+	words := "abandon abandon abandon abandon abandon abandn abandon abandon abandon abandon abandon about"
+
+	validation, err := ValidateMnemonic(words, "")
+	assert.Nil(t, err)
+	assert.False(t, validation.IsValid)
+	assert.True(t, validation.IsWordCountValid)
+	assert.False(t, validation.IsChecksumValid)
+	assert.False(t, validation.ChecksumOnlyFailure)
+	assert.Equal(t, 1, validation.UnknownWordCount)
+
+	word := validation.WordValidationAtIndex(5)
+	assert.Equal(t, 5, word.Index)
+	assert.Equal(t, "abandn", word.Word)
+	assert.False(t, word.IsKnown)
+	assert.Equal(t, "abandon", strings.Fields(word.Suggestions)[0])
+
+	// the candidates for the unknown slot include the original word
+	candidates := strings.Fields(validation.ChecksumCandidates)
+	assert.Contains(t, candidates, "abandon")
+	assert.True(t, len(candidates) > 1)
+	for _, candidate := range candidates {
+		trial := strings.Replace(words, "abandn", candidate, 1)
+		_, err := DetectMnemonicLanguage(trial)
+		assert.Nil(t, err)
+	}
+}
+
+func TestValidateMnemonic_UnknownFinalWord(t *testing.T) {
+	validation, err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abot", "")
+	assert.Nil(t, err)
+
+	candidates := strings.Fields(validation.ChecksumCandidates)
+	assert.Equal(t, 128, len(candidates)) // 7 free bits of the final word for 12 words
+	assert.Contains(t, candidates, "about")
+	assert.Equal(t, "about", strings.Fields(validation.WordValidationAtIndex(11).Suggestions)[0])
+}
+
+func TestValidateMnemonic_MissingFinalWord(t *testing.T) {
+	validation, err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "")
+	assert.Nil(t, err)
+	assert.False(t, validation.IsValid)
+	assert.False(t, validation.IsWordCountValid)
+	assert.Equal(t, 0, validation.UnknownWordCount)
+
+	candidates := strings.Fields(validation.ChecksumCandidates)
+	assert.Equal(t, 128, len(candidates))
+	assert.Contains(t, candidates, "about")
+
+	// 23 words of a 24 word mnemonic leave 3 free bits for the final word
+	words := strings.Fields("letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless")
+	validation, err = ValidateMnemonic(strings.Join(words[:23], " "), "")
+	assert.Nil(t, err)
+	candidates = strings.Fields(validation.ChecksumCandidates)
+	assert.Equal(t, 8, len(candidates))
+	assert.Contains(t, candidates, "bless")
+
+	// an unknown word as well leaves too much to guess
+	validation, err = ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandn", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", validation.ChecksumCandidates)
+}
+
+func TestValidateMnemonic_ChecksumOnlyFailure(t *testing.T) {
+	validation, err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "")
+	assert.Nil(t, err)
+	assert.False(t, validation.IsValid)
+	assert.True(t, validation.IsWordCountValid)
+	assert.False(t, validation.IsChecksumValid)
+	assert.True(t, validation.ChecksumOnlyFailure)
+	assert.Equal(t, 0, validation.UnknownWordCount)
+	assert.Equal(t, "", validation.ChecksumCandidates)
+}
+
+func TestValidateMnemonic_InvalidWordCount(t *testing.T) {
+	validation, err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	assert.Nil(t, err)
+	assert.False(t, validation.IsValid)
+	assert.False(t, validation.IsWordCountValid)
+	assert.False(t, validation.ChecksumOnlyFailure)
+	assert.Equal(t, 11, validation.WordCount)
+}
+
+func TestValidateMnemonic_PrefixSuggestions(t *testing.T) {
+	validation, err := ValidateMnemonic("aban", BIP39LanguageEnglish)
+	assert.Nil(t, err)
+	suggestions := strings.Fields(validation.WordValidationAtIndex(0).Suggestions)
+	assert.Equal(t, "abandon", suggestions[0])
+	assert.True(t, len(suggestions) <= maxWordSuggestions)
+}
+
+func TestValidateMnemonic_DetectsLanguageFromMostWords(t *testing.T) {
+	validation, err := ValidateMnemonic("ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco abdomn", "")
+	assert.Nil(t, err)
+	assert.Equal(t, BIP39LanguageSpanish, validation.Language)
+	assert.Equal(t, 1, validation.UnknownWordCount)
+	assert.Equal(t, "abdomen", strings.Fields(validation.WordValidationAtIndex(11).Suggestions)[0])
+}
+
+func TestValidateMnemonic_UnsupportedLanguage(t *testing.T) {
+	validation, err := ValidateMnemonic(w, "klingon")
+	assert.Equal(t, ErrUnsupportedMnemonicLanguage, err)
+	assert.Nil(t, validation)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("zoo", "zoo"))
+	assert.Equal(t, 1, editDistance("abandn", "abandon"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
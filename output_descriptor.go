@@ -0,0 +1,296 @@
+package cnlib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// Output descriptors (BIP380-386) describe the scripts of a wallet. The descriptors produced and accepted here are the
+// single-key forms for the standard purposes, with a ranged account key on one chain, e.g.
+//
+//	wpkh([d34db33f/84'/0'/0']xpub.../0/*)#checksum
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	descriptorChecksumLength  = 8
+)
+
+var descriptorScripts = map[int][2]string{
+	bip44purpose: {"pkh(", ")"},
+	bip49purpose: {"sh(wpkh(", "))"},
+	bip84purpose: {"wpkh(", ")"},
+	bip86purpose: {"tr(", ")"},
+}
+
+var (
+	// ErrInvalidDescriptor describes an error in which an output descriptor cannot be parsed or is not a supported form.
+	ErrInvalidDescriptor = errors.New("invalid or unsupported output descriptor")
+
+	// ErrInvalidDescriptorChecksum describes an error in which an output descriptor's checksum does not match.
+	ErrInvalidDescriptorChecksum = errors.New("invalid output descriptor checksum")
+)
+
+/// Receiver methods
+
+// ReceiveOutputDescriptor returns the descriptor, with checksum, for the receive chain of the current BaseCoin's account.
+func (wallet *HDWallet) ReceiveOutputDescriptor() (string, error) {
+	return wallet.outputDescriptor(wallet.coin(), 0)
+}
+
+// ChangeOutputDescriptor returns the descriptor, with checksum, for the change chain of the current BaseCoin's account.
+func (wallet *HDWallet) ChangeOutputDescriptor() (string, error) {
+	return wallet.outputDescriptor(wallet.coin(), 1)
+}
+
+func (wallet *HDWallet) outputDescriptor(basecoin *BaseCoin, change int) (string, error) {
+	script, ok := descriptorScripts[basecoin.Purpose]
+	if !ok {
+		return "", ErrInvalidPurposeValue
+	}
+
+	var accountKey *hdkeychain.ExtendedKey
+	var origin *KeyOrigin
+	if wallet.masterPrivateKey != nil {
+		kf := wallet.keyFactory()
+		acctPriv, err := kf.accountPrivateKey(basecoin)
+		if err != nil {
+			return "", err
+		}
+		accountKey, err = acctPriv.Neuter()
+		if err != nil {
+			return "", err
+		}
+		origin, err = wallet.accountKeyOriginForCoin(basecoin)
+		if err != nil {
+			return "", err
+		}
+	} else if wallet.accountPublicKey != nil {
+		accountKey = wallet.accountPublicKey
+		// without an origin for the current coin's account, the key is described on its own
+		origin, _ = wallet.accountKeyOriginForCoin(basecoin)
+	} else {
+		return "", errors.New("no valid master private key or account extended public key found")
+	}
+
+	key, err := standardExtendedPublicKey(accountKey, basecoin.defaultNetParams())
+	if err != nil {
+		return "", err
+	}
+	if origin != nil {
+		key = "[" + origin.String() + "]" + key
+	}
+
+	return AddDescriptorChecksum(fmt.Sprintf("%s%s/%d/*%s", script[0], key, change, script[1]))
+}
+
+/// Constructors
+
+// NewHDWalletFromOutputDescriptor returns a watch-only HDWallet for a pkh, sh(wpkh), wpkh or tr descriptor of a ranged
+// account key on the receive or change chain (e.g. "/0/*" or "/<0;1>/*"). A checksum, if present, must be valid.
+// The BaseCoin is taken from the script type, the key's network, and the account in the key origin or key itself.
+func NewHDWalletFromOutputDescriptor(descriptor string) (*HDWallet, error) {
+	desc, err := stripDescriptorChecksum(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	purpose := -1
+	var inner string
+	for p, script := range descriptorScripts {
+		if strings.HasPrefix(desc, script[0]) && strings.HasSuffix(desc, script[1]) && len(desc) > len(script[0])+len(script[1]) {
+			purpose = p
+			inner = desc[len(script[0]) : len(desc)-len(script[1])]
+			break
+		}
+	}
+	if purpose == -1 {
+		return nil, ErrInvalidDescriptor
+	}
+
+	origin, keyExpr, err := parseDescriptorKeyOrigin(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	keyString, err := stripRangedChain(keyExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hdkeychain.NewKeyFromString(keyString)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsPrivate() || key.Depth() != 3 {
+		return nil, ErrInvalidDescriptor
+	}
+
+	coin := mainnet
+	if key.IsForNet(&chaincfg.TestNet3Params) {
+		coin = testnet
+	} else if !key.IsForNet(&chaincfg.MainNetParams) {
+		return nil, ErrInvalidDescriptor
+	}
+
+	account := int(extendedKeyChildNumber(key) &^ hdkeychain.HardenedKeyStart)
+	if origin != nil {
+		if len(origin.path) != 3 {
+			return nil, ErrInvalidDescriptor
+		}
+		if origin.path[0] != hardened(purpose) || origin.path[1] != hardened(coin) || origin.path[2] != extendedKeyChildNumber(key) {
+			return nil, ErrInvalidDescriptor
+		}
+	}
+
+	basecoin := NewBaseCoin(purpose, coin, account)
+	wallet := HDWallet{baseCoin: basecoin, WalletWords: "", masterPrivateKey: nil, accountPublicKey: key, accountKeyOrigin: origin, keyCache: newKeyCache()}
+	return &wallet, nil
+}
+
+/// Package functions
+
+// DescriptorChecksum returns the 8 character BIP380 checksum of a descriptor without its "#checksum" suffix.
+func DescriptorChecksum(descriptor string) (string, error) {
+	symbols := make([]uint64, 0, len(descriptor)*2)
+	groups := make([]uint64, 0, 3)
+	for _, ch := range descriptor {
+		v := strings.IndexRune(descriptorInputCharset, ch)
+		if v < 0 {
+			return "", ErrInvalidDescriptor
+		}
+		symbols = append(symbols, uint64(v)&31)
+		groups = append(groups, uint64(v)>>5)
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+	if len(groups) == 1 {
+		symbols = append(symbols, groups[0])
+	} else if len(groups) == 2 {
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+
+	symbols = append(symbols, make([]uint64, descriptorChecksumLength)...)
+	c := descriptorPolymod(symbols) ^ 1
+
+	checksum := make([]byte, descriptorChecksumLength)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*(7-uint(i))))&31]
+	}
+	return string(checksum), nil
+}
+
+// AddDescriptorChecksum returns the descriptor with its "#checksum" suffix appended.
+func AddDescriptorChecksum(descriptor string) (string, error) {
+	checksum, err := DescriptorChecksum(descriptor)
+	if err != nil {
+		return "", err
+	}
+	return descriptor + "#" + checksum, nil
+}
+
+// ValidateDescriptorChecksum returns an error if a descriptor has no checksum, or its checksum does not match.
+func ValidateDescriptorChecksum(descriptor string) error {
+	if !strings.Contains(descriptor, "#") {
+		return ErrInvalidDescriptorChecksum
+	}
+	_, err := stripDescriptorChecksum(descriptor)
+	return err
+}
+
+/// Unexported functions
+
+func descriptorPolymod(symbols []uint64) uint64 {
+	generators := []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+	c := uint64(1)
+	for _, value := range symbols {
+		top := c >> 35
+		c = (c&0x7ffffffff)<<5 ^ value
+		for i, g := range generators {
+			if (top>>uint(i))&1 == 1 {
+				c ^= g
+			}
+		}
+	}
+	return c
+}
+
+// stripDescriptorChecksum validates and removes a "#checksum" suffix, if there is one.
+func stripDescriptorChecksum(descriptor string) (string, error) {
+	sep := strings.LastIndexByte(descriptor, '#')
+	if sep < 0 {
+		return descriptor, nil
+	}
+	desc, checksum := descriptor[:sep], descriptor[sep+1:]
+	expected, err := DescriptorChecksum(desc)
+	if err != nil {
+		return "", err
+	}
+	if checksum != expected {
+		return "", ErrInvalidDescriptorChecksum
+	}
+	return desc, nil
+}
+
+// parseDescriptorKeyOrigin splits an optional "[fingerprint/path]" prefix from a key expression.
+func parseDescriptorKeyOrigin(expr string) (*KeyOrigin, string, error) {
+	if !strings.HasPrefix(expr, "[") {
+		return nil, expr, nil
+	}
+	end := strings.IndexByte(expr, ']')
+	if end < 0 {
+		return nil, "", ErrInvalidDescriptor
+	}
+
+	origin, err := ParseKeyOrigin(expr[1:end])
+	if err != nil {
+		return nil, "", ErrInvalidDescriptor
+	}
+	return origin, expr[end+1:], nil
+}
+
+// stripRangedChain removes the unhardened "/0/*", "/1/*" or "/<0;1>/*" suffix from an extended key expression.
+func stripRangedChain(expr string) (string, error) {
+	for _, suffix := range []string{"/0/*", "/1/*", "/<0;1>/*"} {
+		if strings.HasSuffix(expr, suffix) {
+			return strings.TrimSuffix(expr, suffix), nil
+		}
+	}
+	return "", ErrInvalidDescriptor
+}
+
+// extendedKeyChildNumber returns the child number serialized in an extended key.
+func extendedKeyChildNumber(key *hdkeychain.ExtendedKey) uint32 {
+	decoded, _, err := base58.CheckDecode(key.String())
+	if err != nil {
+		return 0
+	}
+	// CheckDecode strips the first version byte: version(3) | depth(1) | parent fingerprint(4) | child number(4)
+	return binary.BigEndian.Uint32(decoded[8:12])
+}
+
+// standardExtendedPublicKey serializes an extended public key with the xpub/tpub version bytes used by descriptors.
+func standardExtendedPublicKey(key *hdkeychain.ExtendedKey, params *chaincfg.Params) (string, error) {
+	if key.IsPrivate() {
+		neutered, err := key.Neuter()
+		if err != nil {
+			return "", err
+		}
+		key = neutered
+	}
+
+	decoded, _, err := base58.CheckDecode(key.String())
+	if err != nil {
+		return "", err
+	}
+	serialized := append([]byte{}, params.HDPublicKeyID[:]...)
+	serialized = append(serialized, decoded[3:]...)
+	return base58.CheckEncode(serialized[1:], serialized[0]), nil
+}
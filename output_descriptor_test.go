@@ -0,0 +1,131 @@
+package cnlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptorChecksum(t *testing.T) {
+	checksum, err := DescriptorChecksum("raw(deadbeef)")
+	assert.Nil(t, err)
+	assert.Equal(t, "89f8spxm", checksum)
+
+	desc, err := AddDescriptorChecksum("pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)")
+	assert.Nil(t, err)
+	assert.Equal(t, "pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)#ml40v0wf", desc)
+
+	assert.Nil(t, ValidateDescriptorChecksum("raw(deadbeef)#89f8spxm"))
+	assert.Equal(t, ErrInvalidDescriptorChecksum, ValidateDescriptorChecksum("raw(deadbeef)#89f8spxn"))
+	assert.Equal(t, ErrInvalidDescriptorChecksum, ValidateDescriptorChecksum("raw(deadbeef)#"))
+	assert.Equal(t, ErrInvalidDescriptorChecksum, ValidateDescriptorChecksum("raw(deadbeef)"))
+
+	_, err = DescriptorChecksum("raw(deadbeef)\n")
+	assert.Equal(t, ErrInvalidDescriptor, err)
+}
+
+func TestHDWallet_OutputDescriptors(t *testing.T) {
+	expected := map[int]string{
+		44: "pkh([73c5da0a/44'/0'/0']xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj/0/*)#8w4z8fed",
+		49: "sh(wpkh([73c5da0a/49'/0'/0']xpub6C6nQwHaWbSrzs5tZ1q7m5R9cPK9eYpNMFesiXsYrgc1P8bvLLAet9JfHjYXKjToD8cBRswJXXbbFpXgwsswVPAZzKMa1jUp2kVkGVUaJa7/0/*))#gvfpdstz",
+		84: "wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0/*)#wc3n3van",
+		86: "tr([73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)#rg247h69",
+	}
+
+	for purpose, desc := range expected {
+		wallet := NewHDWalletFromWords(w, NewBaseCoin(purpose, 0, 0))
+		receive, err := wallet.ReceiveOutputDescriptor()
+		assert.Nil(t, err)
+		assert.Equal(t, desc, receive)
+
+		change, err := wallet.ChangeOutputDescriptor()
+		assert.Nil(t, err)
+		assert.Nil(t, ValidateDescriptorChecksum(change))
+		assert.Contains(t, change, "/1/*")
+	}
+
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84TestNet)
+	change, err := wallet.ChangeOutputDescriptor()
+	assert.Nil(t, err)
+	assert.Equal(t, "wpkh([73c5da0a/84'/1'/0']tpubDC8msFGeGuwnKG9Upg7DM2b4DaRqg3CUZa5g8v2SRQ6K4NSkxUgd7HsL2XVWbVm39yBA4LAxysQAm397zwQSQoQgewGiYZqrA9DsP4zbQ1M/1/*)#mfdmwng4", change)
+}
+
+func TestNewHDWalletFromOutputDescriptor_RoundTrip(t *testing.T) {
+	for _, purpose := range []int{44, 49, 84, 86} {
+		for _, coin := range []int{0, 1} {
+			basecoin := NewBaseCoin(purpose, coin, 2)
+			wallet := NewHDWalletFromWords(w, basecoin)
+			desc, err := wallet.ReceiveOutputDescriptor()
+			assert.Nil(t, err)
+
+			watchOnly, err := NewHDWalletFromOutputDescriptor(desc)
+			assert.Nil(t, err)
+			assert.Equal(t, basecoin, watchOnly.BaseCoin())
+
+			for i := 0; i < 3; i++ {
+				// MessageSigningAddressForIndex, as BIP86 change addresses are not handed out
+				expected, err := wallet.MessageSigningAddressForIndex(1, i)
+				assert.Nil(t, err)
+				actual, err := watchOnly.MessageSigningAddressForIndex(1, i)
+				assert.Nil(t, err)
+				assert.Equal(t, expected.Address, actual.Address)
+			}
+
+			// the origin is carried through, so the watch-only wallet exports the same descriptor
+			exported, err := watchOnly.ReceiveOutputDescriptor()
+			assert.Nil(t, err)
+			assert.Equal(t, desc, exported)
+		}
+	}
+}
+
+func TestNewHDWalletFromOutputDescriptor_Forms(t *testing.T) {
+	key := "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+
+	// no checksum, no origin, multipath chain
+	wallet, err := NewHDWalletFromOutputDescriptor("wpkh(" + key + "/<0;1>/*)")
+	assert.Nil(t, err)
+	assert.Equal(t, BaseCoinBip84MainNet, wallet.BaseCoin())
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", ma.Address)
+
+	zpub, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs", zpub)
+
+	// h as the hardened marker
+	_, err = NewHDWalletFromOutputDescriptor("wpkh([73c5da0a/84h/0h/0h]" + key + "/0/*)")
+	assert.Nil(t, err)
+
+	// an xpub imported from SLIP-132 form exports without an origin
+	watchOnly, err := NewHDWalletFromAccountExtendedPublicKey(zpub)
+	assert.Nil(t, err)
+	desc, err := watchOnly.ReceiveOutputDescriptor()
+	assert.Nil(t, err)
+	assert.Equal(t, "wpkh("+key+"/0/*)", desc[:len(desc)-9])
+}
+
+func TestNewHDWalletFromOutputDescriptor_Invalid(t *testing.T) {
+	key := "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+
+	_, err := NewHDWalletFromOutputDescriptor("wpkh([73c5da0a/84'/0'/0']" + key + "/0/*)#wc3n3vaa")
+	assert.Equal(t, ErrInvalidDescriptorChecksum, err)
+
+	invalid := []string{
+		"wsh(" + key + "/0/*)",                      // unsupported script
+		"wpkh(" + key + ")",                         // not ranged
+		"wpkh(" + key + "/0/*')",                    // hardened range
+		"wpkh(" + key + "/2/*)",                     // not a standard chain
+		"wpkh([73c5da0a/49'/0'/0']" + key + "/0/*)", // origin purpose does not match script
+		"wpkh([73c5da0a/84'/0'/1']" + key + "/0/*)", // origin account does not match key
+		"wpkh([73c5da0a/84'/0']" + key + "/0/*)",    // origin too short
+		"wpkh([73c5da/84'/0'/0']" + key + "/0/*)",   // short fingerprint
+		"wpkh([73c5da0a/84'/0'/0'" + key + "/0/*)",  // unterminated origin
+		"wpkh(xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8/0/*)", // master key
+	}
+	for _, desc := range invalid {
+		_, err := NewHDWalletFromOutputDescriptor(desc)
+		assert.NotNilf(t, err, desc)
+	}
+}
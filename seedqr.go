@@ -0,0 +1,104 @@
+package cnlib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const seedQRDigitsPerWord = 4
+
+var (
+	// ErrInvalidSeedQR describes an error in which SeedQR data is not a 12 or 24 word English mnemonic with a valid checksum.
+	ErrInvalidSeedQR = errors.New("invalid SeedQR")
+)
+
+/// Receiver methods
+
+// StandardSeedQR returns the wallet's WalletWords as a Standard SeedQR digit stream.
+func (wallet *HDWallet) StandardSeedQR() (string, error) {
+	return EncodeStandardSeedQR(wallet.WalletWords)
+}
+
+// CompactSeedQR returns the wallet's WalletWords as Compact SeedQR entropy bytes.
+func (wallet *HDWallet) CompactSeedQR() ([]byte, error) {
+	return EncodeCompactSeedQR(wallet.WalletWords)
+}
+
+/// Package functions
+
+// EncodeStandardSeedQR returns a 12 or 24 word English mnemonic as a Standard SeedQR: each word's wordlist index as
+// four zero-padded decimal digits.
+func EncodeStandardSeedQR(wordString string) (string, error) {
+	words, err := seedQRWords(wordString)
+	if err != nil {
+		return "", err
+	}
+
+	indexes := bip39WordIndexes()[BIP39LanguageEnglish]
+	var digits strings.Builder
+	for _, word := range words {
+		fmt.Fprintf(&digits, "%04d", indexes[word])
+	}
+	return digits.String(), nil
+}
+
+// EncodeCompactSeedQR returns a 12 or 24 word English mnemonic as a Compact SeedQR: its 16 or 32 bytes of entropy,
+// without the checksum.
+func EncodeCompactSeedQR(wordString string) ([]byte, error) {
+	words, err := seedQRWords(wordString)
+	if err != nil {
+		return nil, err
+	}
+	return mnemonicEntropy(words, BIP39LanguageEnglish)
+}
+
+// DecodeStandardSeedQR returns the space-separated English mnemonic in a Standard SeedQR digit stream. Returns
+// ErrInvalidSeedQR if the digits are malformed or the mnemonic checksum does not match.
+func DecodeStandardSeedQR(digits string) (string, error) {
+	if len(digits) != 12*seedQRDigitsPerWord && len(digits) != 24*seedQRDigitsPerWord {
+		return "", ErrInvalidSeedQR
+	}
+
+	list := bip39Wordlists[BIP39LanguageEnglish]
+	words := make([]string, 0, len(digits)/seedQRDigitsPerWord)
+	for i := 0; i < len(digits); i += seedQRDigitsPerWord {
+		chunk := digits[i : i+seedQRDigitsPerWord]
+		if strings.IndexFunc(chunk, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+			return "", ErrInvalidSeedQR
+		}
+		index, err := strconv.Atoi(chunk)
+		if err != nil || index >= len(list) {
+			return "", ErrInvalidSeedQR
+		}
+		words = append(words, list[index])
+	}
+
+	if _, err := mnemonicEntropy(words, BIP39LanguageEnglish); err != nil {
+		return "", ErrInvalidSeedQR
+	}
+	return strings.Join(words, " "), nil
+}
+
+// DecodeCompactSeedQR returns the space-separated English mnemonic for Compact SeedQR entropy bytes.
+func DecodeCompactSeedQR(entropy []byte) (string, error) {
+	if len(entropy) != 16 && len(entropy) != 32 {
+		return "", ErrInvalidSeedQR
+	}
+	return NewWordListFromEntropy(entropy)
+}
+
+/// Unexported functions
+
+// seedQRWords splits a mnemonic into words, requiring 12 or 24 English words with a valid checksum.
+func seedQRWords(wordString string) ([]string, error) {
+	words := strings.Fields(strings.ToLower(wordString))
+	if len(words) != 12 && len(words) != 24 {
+		return nil, ErrInvalidSeedQR
+	}
+	if _, err := mnemonicEntropy(words, BIP39LanguageEnglish); err != nil {
+		return nil, ErrInvalidSeedQR
+	}
+	return words, nil
+}
@@ -0,0 +1,100 @@
+package cnlib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SeedQR specification test vector
+const (
+	seedQRTestWords    = "attack pizza motion avocado network gather crop fresh patrol unusual wild holiday candy pony ranch winter theme error hybrid van cereal salon goddess expire"
+	seedQRTestStandard = "011513251154012711900771041507421289190620080870026613431420201617920614089619290300152408010643"
+)
+
+func TestEncodeStandardSeedQR(t *testing.T) {
+	digits, err := EncodeStandardSeedQR(w)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("0000", 11)+"0003", digits)
+
+	digits, err = EncodeStandardSeedQR(seedQRTestWords)
+	assert.Nil(t, err)
+	assert.Equal(t, seedQRTestStandard, digits)
+}
+
+func TestDecodeStandardSeedQR(t *testing.T) {
+	words, err := DecodeStandardSeedQR(seedQRTestStandard)
+	assert.Nil(t, err)
+	assert.Equal(t, seedQRTestWords, words)
+
+	words, err = DecodeStandardSeedQR(strings.Repeat("0000", 11) + "0003")
+	assert.Nil(t, err)
+	assert.Equal(t, w, words)
+}
+
+func TestDecodeStandardSeedQR_Invalid(t *testing.T) {
+	invalid := []string{
+		"",
+		strings.Repeat("0000", 11) + "000",           // too short
+		strings.Repeat("0000", 11) + "0004",          // bad checksum
+		strings.Repeat("0000", 11) + "2048",          // index out of range
+		strings.Repeat("0000", 11) + "+003",          // not a digit
+		strings.Repeat("0000", 17) + "0000000000003", // unsupported word count
+	}
+	for _, digits := range invalid {
+		words, err := DecodeStandardSeedQR(digits)
+		assert.Equal(t, "", words, digits)
+		assert.Equal(t, ErrInvalidSeedQR, err, digits)
+	}
+}
+
+func TestCompactSeedQR_RoundTrip(t *testing.T) {
+	entropy, err := EncodeCompactSeedQR(w)
+	assert.Nil(t, err)
+	assert.Equal(t, make([]byte, 16), entropy)
+
+	entropy, err = EncodeCompactSeedQR(seedQRTestWords)
+	assert.Nil(t, err)
+	assert.Equal(t, 32, len(entropy))
+
+	words, err := DecodeCompactSeedQR(entropy)
+	assert.Nil(t, err)
+	assert.Equal(t, seedQRTestWords, words)
+
+	expected, _ := hex.DecodeString("ffffffffffffffffffffffffffffffff")
+	words, err = DecodeCompactSeedQR(bytes.Repeat([]byte{0xff}, 16))
+	assert.Nil(t, err)
+	entropy, _ = EncodeCompactSeedQR(words)
+	assert.Equal(t, expected, entropy)
+}
+
+func TestSeedQR_InvalidInput(t *testing.T) {
+	_, err := DecodeCompactSeedQR(make([]byte, 24))
+	assert.Equal(t, ErrInvalidSeedQR, err)
+
+	fifteenWords := strings.Repeat("abandon ", 14) + "address"
+	_, err = EncodeStandardSeedQR(fifteenWords)
+	assert.Equal(t, ErrInvalidSeedQR, err)
+	_, err = EncodeCompactSeedQR(strings.Replace(w, "about", "abandon", 1))
+	assert.Equal(t, ErrInvalidSeedQR, err)
+}
+
+func TestHDWallet_SeedQR(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, NewBaseCoin(84, 0, 0))
+	digits, err := wallet.StandardSeedQR()
+	assert.Nil(t, err)
+	words, _ := DecodeStandardSeedQR(digits)
+	assert.Equal(t, w, words)
+
+	entropy, err := wallet.CompactSeedQR()
+	assert.Nil(t, err)
+	words, _ = DecodeCompactSeedQR(entropy)
+	assert.Equal(t, w, words)
+
+	watchOnly, _ := NewHDWalletFromAccountExtendedPublicKey(bip84AccountZpub)
+	_, err = watchOnly.StandardSeedQR()
+	assert.Equal(t, ErrInvalidSeedQR, err)
+}
@@ -0,0 +1,142 @@
+package cnlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// rawSignatureSize is the size of a compact r || s signature, each 32 bytes big endian.
+const rawSignatureSize = 64
+
+var (
+	// ErrInvalidSignatureEncoding describes an error in which a signature is neither strict DER nor a raw 64 byte r || s value.
+	ErrInvalidSignatureEncoding = errors.New("invalid signature encoding")
+
+	// ErrHighSSignature describes an error in which a signature's S value is not in the lower half of the curve order.
+	ErrHighSSignature = errors.New("signature S value is not canonical (low-S)")
+
+	// ErrRequestTimestampSkew describes an error in which a signed request's timestamp is too far from the current time.
+	ErrRequestTimestampSkew = errors.New("request timestamp outside allowed skew")
+
+	// ErrInvalidRequestField describes an error in which a request's method or path contains a newline, which would make its canonical string ambiguous.
+	ErrInvalidRequestField = errors.New("request method or path contains a newline")
+)
+
+var halfCurveOrder = new(big.Int).Rsh(btcec.S256().N, 1)
+
+/// Type Definition
+
+// RequestSigningData holds the parts of an HTTP request covered by a request signature.
+type RequestSigningData struct {
+	Method    string
+	Path      string
+	Body      []byte
+	Timestamp int64
+}
+
+/// Constructors
+
+// NewRequestSigningData returns a pointer to a RequestSigningData for the given request parts, with timestamp in unix seconds.
+func NewRequestSigningData(method string, path string, body []byte, timestamp int64) *RequestSigningData {
+	return &RequestSigningData{Method: method, Path: path, Body: body, Timestamp: timestamp}
+}
+
+/// Receiver methods
+
+// CanonicalString returns the string that is signed: upper-cased method, path, hex sha256 of body and timestamp, newline separated.
+// Returns ErrInvalidRequestField if the method or path contains a newline.
+func (r *RequestSigningData) CanonicalString() (string, error) {
+	if strings.Contains(r.Method, "\n") || strings.Contains(r.Path, "\n") {
+		return "", ErrInvalidRequestField
+	}
+
+	bodyHash := sha256.Sum256(r.Body)
+	return strings.Join([]string{
+		strings.ToUpper(r.Method),
+		r.Path,
+		hex.EncodeToString(bodyHash[:]),
+		strconv.FormatInt(r.Timestamp, 10),
+	}, "\n"), nil
+}
+
+/// Package functions
+
+// VerifySignature verifies a signature produced by SignData against a compressed or uncompressed public key.
+// The signature may be DER or raw 64 byte r || s, and must be low-S.
+func VerifySignature(message []byte, signature []byte, publicKey []byte) (bool, error) {
+	pubkey, err := btcec.ParsePubKey(publicKey, btcec.S256())
+	if err != nil {
+		return false, err
+	}
+
+	sig, err := parseSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	messageHash := chainhash.DoubleHashB(message)
+	return sig.Verify(messageHash, pubkey), nil
+}
+
+// VerifySignatureHexString verifies a hex-encoded signature, as returned by SignatureSigningData, against a hex-encoded public key.
+func VerifySignatureHexString(message []byte, signature string, publicKey string) (bool, error) {
+	sigBytes, err := hex.DecodeString(signature)
+	if err != nil {
+		return false, ErrInvalidSignatureEncoding
+	}
+
+	pubkeyBytes, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return false, err
+	}
+
+	return VerifySignature(message, sigBytes, pubkeyBytes)
+}
+
+// VerifyRequestSignature verifies a hex-encoded request signature, rejecting timestamps more than maxSkewSeconds from now (unix seconds).
+func VerifyRequestSignature(request *RequestSigningData, signature string, publicKey string, now int64, maxSkewSeconds int) (bool, error) {
+	skew := now - request.Timestamp
+	if skew < 0 {
+		skew = -skew
+	}
+	if maxSkewSeconds < 0 || skew > int64(maxSkewSeconds) {
+		return false, ErrRequestTimestampSkew
+	}
+
+	canonical, err := request.CanonicalString()
+	if err != nil {
+		return false, err
+	}
+
+	return VerifySignatureHexString([]byte(canonical), signature, publicKey)
+}
+
+// parseSignature accepts strict DER or raw r || s encodings and enforces low-S. DER is tried first, as a DER signature
+// with a short r or s can itself be 64 bytes long.
+func parseSignature(signature []byte) (*btcec.Signature, error) {
+	sig, err := btcec.ParseDERSignature(signature, btcec.S256())
+	if err != nil {
+		if len(signature) != rawSignatureSize {
+			return nil, ErrInvalidSignatureEncoding
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(btcec.S256().N) >= 0 || s.Cmp(btcec.S256().N) >= 0 {
+			return nil, ErrInvalidSignatureEncoding
+		}
+		sig = &btcec.Signature{R: r, S: s}
+	}
+
+	if sig.S.Cmp(halfCurveOrder) > 0 {
+		return nil, ErrHighSSignature
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,189 @@
+package cnlib
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature_DERAndRaw(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	message := []byte("Hello World")
+
+	der, err := wallet.SignData(message)
+	assert.Nil(t, err)
+	pubkey, err := wallet.SigningPublicKey()
+	assert.Nil(t, err)
+
+	valid, err := VerifySignature(message, der, pubkey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	sig, err := btcec.ParseDERSignature(der, btcec.S256())
+	assert.Nil(t, err)
+	raw := append(padTo32(sig.R.Bytes()), padTo32(sig.S.Bytes())...)
+	valid, err = VerifySignature(message, raw, pubkey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	// uncompressed public key works as well
+	priv, err := wallet.signingPrivateKey()
+	assert.Nil(t, err)
+	valid, err = VerifySignature(message, der, priv.PubKey().SerializeUncompressed())
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, err = VerifySignature([]byte("Hello World!"), der, pubkey)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignature_64ByteDER(t *testing.T) {
+	// r and s are 29 bytes each, so the strict DER encoding is exactly as long as a raw r || s signature
+	sig := "303e021d0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d021d1d1c1b1a191817161514131211100f0e0d0c0b0a090807060504030201"
+	pubkey := "0270d489e4eefacd0d575cc3dfd9be65e33c648560ec6f2a871bb3581e63bcfb95"
+	assert.Equal(t, rawSignatureSize*2, len(sig))
+
+	valid, err := VerifySignatureHexString([]byte("cnlib"), sig, pubkey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, err = VerifySignatureHexString([]byte("cnlib!"), sig, pubkey)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignatureHexString(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	message := []byte("Hello World")
+
+	sig, err := wallet.SignatureSigningData(message)
+	assert.Nil(t, err)
+	pubkey, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	valid, err := VerifySignatureHexString(message, sig, pubkey)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	otherWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	otherPubkey, err := otherWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	valid, err = VerifySignatureHexString(message, sig, otherPubkey)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+
+	valid, err = VerifySignatureHexString(message, "zz", pubkey)
+	assert.Equal(t, ErrInvalidSignatureEncoding, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignature_RejectsHighS(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	message := []byte("Hello World")
+
+	der, err := wallet.SignData(message)
+	assert.Nil(t, err)
+	pubkey, err := wallet.SigningPublicKey()
+	assert.Nil(t, err)
+
+	sig, err := btcec.ParseDERSignature(der, btcec.S256())
+	assert.Nil(t, err)
+	highS := new(big.Int).Sub(btcec.S256().N, sig.S)
+
+	// the malleated signature is mathematically valid, but not canonical
+	raw := append(padTo32(sig.R.Bytes()), padTo32(highS.Bytes())...)
+	valid, err := VerifySignature(message, raw, pubkey)
+	assert.Equal(t, ErrHighSSignature, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignature_InvalidEncoding(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	pubkey, err := wallet.SigningPublicKey()
+	assert.Nil(t, err)
+
+	valid, err := VerifySignature([]byte("Hello World"), []byte{0x30, 0x01, 0x02}, pubkey)
+	assert.Equal(t, ErrInvalidSignatureEncoding, err)
+	assert.False(t, valid)
+
+	valid, err = VerifySignature([]byte("Hello World"), make([]byte, rawSignatureSize), pubkey)
+	assert.Equal(t, ErrInvalidSignatureEncoding, err)
+	assert.False(t, valid)
+
+	valid, err = VerifySignature([]byte("Hello World"), make([]byte, rawSignatureSize), []byte{0x02})
+	assert.NotNil(t, err)
+	assert.False(t, valid)
+}
+
+func TestRequestSigningData_CanonicalString(t *testing.T) {
+	req := NewRequestSigningData("post", "/api/v1/wallet", []byte(""), 1560000000)
+	expected := "POST\n/api/v1/wallet\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n1560000000"
+	canonical, err := req.CanonicalString()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, canonical)
+}
+
+func TestRequestSigningData_CanonicalStringRejectsNewlines(t *testing.T) {
+	// "POST" + "/a\nb" would otherwise sign the same string as a request with method "POST\n/a" and path "b"
+	for _, req := range []*RequestSigningData{
+		NewRequestSigningData("POST\n/a", "b", nil, 1560000000),
+		NewRequestSigningData("POST", "/a\nb", nil, 1560000000),
+	} {
+		canonical, err := req.CanonicalString()
+		assert.Equal(t, ErrInvalidRequestField, err)
+		assert.Equal(t, "", canonical)
+	}
+
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	pubkey, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	req := NewRequestSigningData("POST", "/a\nb", nil, 1560000000)
+	sig, err := wallet.SignRequest(req)
+	assert.Equal(t, ErrInvalidRequestField, err)
+	assert.Equal(t, "", sig)
+
+	valid, err := VerifyRequestSignature(req, "00", pubkey, 1560000000, 60)
+	assert.Equal(t, ErrInvalidRequestField, err)
+	assert.False(t, valid)
+}
+
+func TestSignRequest_RoundTrip(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	pubkey, err := wallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	req := NewRequestSigningData("PUT", "/api/v1/wallet/addresses", []byte(`{"address":"bc1q"}`), 1560000000)
+	sig, err := wallet.SignRequest(req)
+	assert.Nil(t, err)
+
+	valid, err := VerifyRequestSignature(req, sig, pubkey, 1560000030, 60)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, err = VerifyRequestSignature(req, sig, pubkey, 1559999940, 60)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	valid, err = VerifyRequestSignature(req, sig, pubkey, 1560000061, 60)
+	assert.Equal(t, ErrRequestTimestampSkew, err)
+	assert.False(t, valid)
+
+	tampered := NewRequestSigningData("PUT", "/api/v1/wallet/addresses", []byte(`{"address":"bc1p"}`), 1560000000)
+	valid, err = VerifyRequestSignature(tampered, sig, pubkey, 1560000000, 60)
+	assert.Nil(t, err)
+	assert.False(t, valid)
+}
+
+// padTo32 left-pads a big-endian integer to 32 bytes.
+func padTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
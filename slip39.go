@@ -0,0 +1,662 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	slip39RadixBits          = 10
+	slip39IDBits             = 15
+	slip39IterationExpBits   = 4
+	slip39ChecksumWords      = 3
+	slip39MetadataWords      = 7 // identifier and iteration exponent (2), share parameters (2), checksum (3)
+	slip39MinMnemonicWords   = 20
+	slip39MaxShareCount      = 16
+	slip39MinSecretSize      = 16
+	slip39DigestSize         = 4
+	slip39DigestIndex        = 254
+	slip39SecretIndex        = 255
+	slip39BaseIterationCount = 10000
+	slip39RoundCount         = 4
+)
+
+var (
+	slip39CustomizationString           = []byte("shamir")
+	slip39ExtendableCustomizationString = []byte("shamir_extendable")
+
+	gf256Exp, gf256Log = gf256Tables()
+
+	slip39ChecksumGenerator = []uint32{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+)
+
+var (
+	// ErrInvalidSLIP39Share describes an error in which a SLIP-39 share mnemonic is malformed, has an unknown word or
+	// fails its checksum.
+	ErrInvalidSLIP39Share = errors.New("invalid SLIP-39 share")
+
+	// ErrInvalidSLIP39Config describes an error in which a SLIP-39 group or member threshold or count is out of range,
+	// or the master secret has an unsupported length.
+	ErrInvalidSLIP39Config = errors.New("invalid SLIP-39 configuration")
+
+	// ErrInsufficientSLIP39Shares describes an error in which too few shares or groups were given to meet the thresholds.
+	ErrInsufficientSLIP39Shares = errors.New("insufficient SLIP-39 shares")
+
+	// ErrMismatchedSLIP39Shares describes an error in which shares from different sets, or conflicting shares, were combined.
+	ErrMismatchedSLIP39Shares = errors.New("SLIP-39 shares do not belong together")
+
+	// ErrInvalidSLIP39Passphrase describes an error in which a SLIP-39 passphrase contains characters other than printable ASCII.
+	ErrInvalidSLIP39Passphrase = errors.New("SLIP-39 passphrase must be printable ASCII")
+)
+
+/// Type Definition
+
+// SLIP39Config describes how to split a master secret: a group threshold, and a member threshold and count per group.
+type SLIP39Config struct {
+	GroupThreshold    int
+	Passphrase        string
+	IterationExponent int
+	Extendable        bool
+	groups            []slip39GroupConfig
+}
+
+type slip39GroupConfig struct {
+	memberThreshold int
+	memberCount     int
+}
+
+// SLIP39Mnemonics is a list of SLIP-39 share mnemonics, each a space-separated string of words.
+type SLIP39Mnemonics struct {
+	mnemonics []string
+}
+
+// SLIP39ShareSet holds the share mnemonics of every group created by a split.
+type SLIP39ShareSet struct {
+	groups []*SLIP39Mnemonics
+}
+
+// slip39Share is a decoded share mnemonic.
+type slip39Share struct {
+	identifier        int
+	extendable        bool
+	iterationExponent int
+	groupIndex        int
+	groupThreshold    int
+	groupCount        int
+	memberIndex       int
+	memberThreshold   int
+	value             []byte
+}
+
+type slip39RawShare struct {
+	x     byte
+	value []byte
+}
+
+/// Constructors
+
+// NewSLIP39Config returns a pointer to a SLIP39Config with a group threshold and no groups. Shares are extendable by
+// default, as the current SLIP-39 revision recommends.
+func NewSLIP39Config(groupThreshold int) *SLIP39Config {
+	return &SLIP39Config{GroupThreshold: groupThreshold, Extendable: true}
+}
+
+// NewSLIP39Mnemonics returns a pointer to an empty SLIP39Mnemonics list.
+func NewSLIP39Mnemonics() *SLIP39Mnemonics {
+	return &SLIP39Mnemonics{}
+}
+
+// NewHDWalletFromSLIP39Shares returns a pointer to an HDWallet whose BIP32 seed is the master secret recombined from
+// SLIP-39 shares.
+func NewHDWalletFromSLIP39Shares(shares *SLIP39Mnemonics, passphrase string, basecoin *BaseCoin) (*HDWallet, error) {
+	if basecoin == nil {
+		return nil, errors.New("no basecoin provided")
+	}
+	seed, err := CombineSLIP39Shares(shares, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := hdkeychain.NewMaster(seed, basecoin.defaultNetParams())
+	if err != nil {
+		return nil, err
+	}
+	primePublicKey(masterKey)
+	return newHDWalletFromMasterKey(masterKey, "", basecoin)
+}
+
+/// Package functions
+
+// SplitSeedSLIP39 splits a master secret, such as a BIP32 seed, into SLIP-39 share mnemonics.
+func SplitSeedSLIP39(masterSecret []byte, config *SLIP39Config) (*SLIP39ShareSet, error) {
+	if config == nil {
+		return nil, ErrInvalidSLIP39Config
+	}
+	if err := config.validate(masterSecret); err != nil {
+		return nil, err
+	}
+	passphrase, err := slip39Passphrase(config.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	idBytes := make([]byte, 2)
+	if _, err := io.ReadFull(entropyReader, idBytes); err != nil {
+		return nil, err
+	}
+	identifier := int(idBytes[0])<<8 | int(idBytes[1])
+	identifier &= 1<<slip39IDBits - 1
+
+	encrypted, err := slip39Encrypt(masterSecret, passphrase, config.IterationExponent, identifier, config.Extendable)
+	if err != nil {
+		return nil, err
+	}
+
+	groupSecrets, err := slip39SplitSecret(config.GroupThreshold, len(config.groups), encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &SLIP39ShareSet{}
+	for groupIndex, group := range config.groups {
+		memberSecrets, err := slip39SplitSecret(group.memberThreshold, group.memberCount, groupSecrets[groupIndex].value)
+		if err != nil {
+			return nil, err
+		}
+
+		mnemonics := NewSLIP39Mnemonics()
+		for _, member := range memberSecrets {
+			share := &slip39Share{
+				identifier:        identifier,
+				extendable:        config.Extendable,
+				iterationExponent: config.IterationExponent,
+				groupIndex:        groupIndex,
+				groupThreshold:    config.GroupThreshold,
+				groupCount:        len(config.groups),
+				memberIndex:       int(member.x),
+				memberThreshold:   group.memberThreshold,
+				value:             member.value,
+			}
+			mnemonics.Add(share.mnemonic())
+		}
+		set.groups = append(set.groups, mnemonics)
+	}
+	return set, nil
+}
+
+// SplitMnemonicSLIP39 splits the BIP32 seed of a BIP39 mnemonic and passphrase into SLIP-39 share mnemonics, so the
+// shares restore the same wallet as NewHDWalletFromMnemonic.
+func SplitMnemonicSLIP39(wordString string, bip39Passphrase string, config *SLIP39Config) (*SLIP39ShareSet, error) {
+	seed, err := mnemonicSeed(wordString, bip39Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return SplitSeedSLIP39(seed, config)
+}
+
+// CombineSLIP39Shares recombines share mnemonics into the master secret, checking the group and member thresholds.
+func CombineSLIP39Shares(shares *SLIP39Mnemonics, passphrase string) ([]byte, error) {
+	if shares == nil || len(shares.mnemonics) == 0 {
+		return nil, ErrInsufficientSLIP39Shares
+	}
+	passphraseBytes, err := slip39Passphrase(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var first *slip39Share
+	groups := make(map[int][]*slip39Share)
+	var groupOrder []int
+	for _, mnemonic := range shares.mnemonics {
+		share, err := decodeSLIP39Share(mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = share
+		} else if !share.matchesSet(first) {
+			return nil, ErrMismatchedSLIP39Shares
+		}
+
+		members, ok := groups[share.groupIndex]
+		if !ok {
+			groupOrder = append(groupOrder, share.groupIndex)
+		}
+		duplicate := false
+		for _, member := range members {
+			if member.memberIndex == share.memberIndex {
+				if !bytes.Equal(member.value, share.value) {
+					return nil, ErrMismatchedSLIP39Shares
+				}
+				duplicate = true
+			} else if member.memberThreshold != share.memberThreshold {
+				return nil, ErrMismatchedSLIP39Shares
+			}
+		}
+		if !duplicate {
+			groups[share.groupIndex] = append(members, share)
+		}
+	}
+
+	var groupSecrets []slip39RawShare
+	for _, groupIndex := range groupOrder {
+		members := groups[groupIndex]
+		if len(members) < members[0].memberThreshold {
+			continue
+		}
+
+		rawMembers := make([]slip39RawShare, 0, members[0].memberThreshold)
+		for _, member := range members[:members[0].memberThreshold] {
+			rawMembers = append(rawMembers, slip39RawShare{x: byte(member.memberIndex), value: member.value})
+		}
+		secret, err := slip39RecoverSecret(members[0].memberThreshold, rawMembers)
+		if err != nil {
+			return nil, err
+		}
+		groupSecrets = append(groupSecrets, slip39RawShare{x: byte(groupIndex), value: secret})
+	}
+
+	if len(groupSecrets) < first.groupThreshold {
+		return nil, ErrInsufficientSLIP39Shares
+	}
+
+	encrypted, err := slip39RecoverSecret(first.groupThreshold, groupSecrets[:first.groupThreshold])
+	if err != nil {
+		return nil, err
+	}
+	return slip39Decrypt(encrypted, passphraseBytes, first.iterationExponent, first.identifier, first.extendable)
+}
+
+/// Receiver methods
+
+// AddGroup adds a group that needs memberThreshold of its memberCount shares.
+func (c *SLIP39Config) AddGroup(memberThreshold int, memberCount int) {
+	c.groups = append(c.groups, slip39GroupConfig{memberThreshold: memberThreshold, memberCount: memberCount})
+}
+
+// GroupCount returns the number of groups configured.
+func (c *SLIP39Config) GroupCount() int {
+	return len(c.groups)
+}
+
+func (c *SLIP39Config) validate(masterSecret []byte) error {
+	if len(masterSecret) < slip39MinSecretSize || len(masterSecret)%2 != 0 {
+		return ErrInvalidSLIP39Config
+	}
+	if c.IterationExponent < 0 || c.IterationExponent >= 1<<slip39IterationExpBits {
+		return ErrInvalidSLIP39Config
+	}
+	if len(c.groups) == 0 || len(c.groups) > slip39MaxShareCount {
+		return ErrInvalidSLIP39Config
+	}
+	if c.GroupThreshold < 1 || c.GroupThreshold > len(c.groups) {
+		return ErrInvalidSLIP39Config
+	}
+	for _, group := range c.groups {
+		if group.memberThreshold < 1 || group.memberThreshold > group.memberCount || group.memberCount > slip39MaxShareCount {
+			return ErrInvalidSLIP39Config
+		}
+		// a 1-of-n group would hand out n copies of the same share
+		if group.memberThreshold == 1 && group.memberCount > 1 {
+			return ErrInvalidSLIP39Config
+		}
+	}
+	return nil
+}
+
+// Add appends a share mnemonic to the list.
+func (m *SLIP39Mnemonics) Add(mnemonic string) {
+	m.mnemonics = append(m.mnemonics, mnemonic)
+}
+
+// Count returns the number of mnemonics in the list.
+func (m *SLIP39Mnemonics) Count() int {
+	return len(m.mnemonics)
+}
+
+// MnemonicAtIndex returns the mnemonic at index i, or an empty string if out of range.
+func (m *SLIP39Mnemonics) MnemonicAtIndex(i int) string {
+	if i < 0 || i >= len(m.mnemonics) {
+		return ""
+	}
+	return m.mnemonics[i]
+}
+
+// GroupCount returns the number of groups in the share set.
+func (s *SLIP39ShareSet) GroupCount() int {
+	return len(s.groups)
+}
+
+// GroupAtIndex returns the share mnemonics of the group at index i, or nil if out of range.
+func (s *SLIP39ShareSet) GroupAtIndex(i int) *SLIP39Mnemonics {
+	if i < 0 || i >= len(s.groups) {
+		return nil
+	}
+	return s.groups[i]
+}
+
+// mnemonic encodes the share as words: identifier, extendable flag and iteration exponent, share parameters, the left
+// zero padded value, and an RS1024 checksum.
+func (s *slip39Share) mnemonic() string {
+	extendable := 0
+	if s.extendable {
+		extendable = 1
+	}
+	idExp := s.identifier<<(slip39IterationExpBits+1) | extendable<<slip39IterationExpBits | s.iterationExponent
+	params := s.groupIndex<<16 | (s.groupThreshold-1)<<12 | (s.groupCount-1)<<8 | s.memberIndex<<4 | (s.memberThreshold - 1)
+
+	valueWordCount := (len(s.value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	data := append(slip39IntToIndices(big.NewInt(int64(idExp)), 2), slip39IntToIndices(big.NewInt(int64(params)), 2)...)
+	data = append(data, slip39IntToIndices(new(big.Int).SetBytes(s.value), valueWordCount)...)
+	data = append(data, slip39CreateChecksum(data, s.extendable)...)
+
+	words := make([]string, len(data))
+	for i, index := range data {
+		words[i] = slip39Wordlist[index]
+	}
+	return strings.Join(words, " ")
+}
+
+// matchesSet returns whether two shares come from the same split.
+func (s *slip39Share) matchesSet(other *slip39Share) bool {
+	return s.identifier == other.identifier &&
+		s.extendable == other.extendable &&
+		s.iterationExponent == other.iterationExponent &&
+		s.groupThreshold == other.groupThreshold &&
+		s.groupCount == other.groupCount &&
+		len(s.value) == len(other.value)
+}
+
+/// Unexported functions
+
+func decodeSLIP39Share(mnemonic string) (*slip39Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < slip39MinMnemonicWords {
+		return nil, ErrInvalidSLIP39Share
+	}
+
+	data := make([]int, len(words))
+	for i, word := range words {
+		index, ok := slip39WordIndex(word)
+		if !ok {
+			return nil, ErrInvalidSLIP39Share
+		}
+		data[i] = index
+	}
+
+	idExp := data[0]<<slip39RadixBits | data[1]
+	share := &slip39Share{
+		identifier:        idExp >> (slip39IterationExpBits + 1),
+		extendable:        (idExp>>slip39IterationExpBits)&1 == 1,
+		iterationExponent: idExp & (1<<slip39IterationExpBits - 1),
+	}
+	if !slip39VerifyChecksum(data, share.extendable) {
+		return nil, ErrInvalidSLIP39Share
+	}
+
+	params := data[2]<<slip39RadixBits | data[3]
+	share.groupIndex = params >> 16
+	share.groupThreshold = (params>>12)&0xf + 1
+	share.groupCount = (params>>8)&0xf + 1
+	share.memberIndex = (params >> 4) & 0xf
+	share.memberThreshold = params&0xf + 1
+	if share.groupThreshold > share.groupCount || share.groupIndex >= share.groupCount {
+		return nil, ErrInvalidSLIP39Share
+	}
+
+	valueWords := data[4 : 4+len(data)-slip39MetadataWords]
+	paddingBits := (slip39RadixBits * len(valueWords)) % 16
+	if paddingBits > 8 {
+		return nil, ErrInvalidSLIP39Share
+	}
+	valueSize := (slip39RadixBits*len(valueWords) - paddingBits) / 8
+
+	value := new(big.Int)
+	for _, index := range valueWords {
+		value.Lsh(value, slip39RadixBits)
+		value.Or(value, big.NewInt(int64(index)))
+	}
+	if value.BitLen() > valueSize*8 {
+		return nil, ErrInvalidSLIP39Share
+	}
+	share.value = make([]byte, valueSize)
+	valueBytes := value.Bytes()
+	copy(share.value[valueSize-len(valueBytes):], valueBytes)
+
+	return share, nil
+}
+
+// slip39WordIndex looks a word up by its unique four letter prefix, so abbreviated words are accepted.
+func slip39WordIndex(word string) (int, bool) {
+	for i, candidate := range slip39Wordlist {
+		if candidate == word || (len(word) >= 4 && strings.HasPrefix(candidate, word)) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func slip39IntToIndices(value *big.Int, count int) []int {
+	indices := make([]int, count)
+	v := new(big.Int).Set(value)
+	mask := big.NewInt(1<<slip39RadixBits - 1)
+	for i := count - 1; i >= 0; i-- {
+		indices[i] = int(new(big.Int).And(v, mask).Int64())
+		v.Rsh(v, slip39RadixBits)
+	}
+	return indices
+}
+
+func slip39Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<slip39RadixBits ^ uint32(v)
+		for i, generator := range slip39ChecksumGenerator {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= generator
+			}
+		}
+	}
+	return chk
+}
+
+func slip39Customization(extendable bool) []int {
+	customization := slip39CustomizationString
+	if extendable {
+		customization = slip39ExtendableCustomizationString
+	}
+	values := make([]int, len(customization))
+	for i, c := range customization {
+		values[i] = int(c)
+	}
+	return values
+}
+
+func slip39CreateChecksum(data []int, extendable bool) []int {
+	values := append(slip39Customization(extendable), data...)
+	values = append(values, 0, 0, 0)
+	polymod := slip39Polymod(values) ^ 1
+
+	checksum := make([]int, slip39ChecksumWords)
+	for i := range checksum {
+		checksum[i] = int(polymod>>uint(slip39RadixBits*(slip39ChecksumWords-1-i))) & (1<<slip39RadixBits - 1)
+	}
+	return checksum
+}
+
+func slip39VerifyChecksum(data []int, extendable bool) bool {
+	return slip39Polymod(append(slip39Customization(extendable), data...)) == 1
+}
+
+func slip39Passphrase(passphrase string) ([]byte, error) {
+	for _, c := range []byte(passphrase) {
+		if c < 32 || c > 126 {
+			return nil, ErrInvalidSLIP39Passphrase
+		}
+	}
+	return []byte(passphrase), nil
+}
+
+// slip39Encrypt runs the four round Feistel network that protects the master secret with the passphrase.
+func slip39Encrypt(masterSecret []byte, passphrase []byte, iterationExponent int, identifier int, extendable bool) ([]byte, error) {
+	half := len(masterSecret) / 2
+	l := append([]byte{}, masterSecret[:half]...)
+	r := append([]byte{}, masterSecret[half:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := 0; i < slip39RoundCount; i++ {
+		f := slip39RoundFunction(i, passphrase, iterationExponent, salt, r)
+		l, r = r, xorBytes(l, f)
+	}
+	return append(r, l...), nil
+}
+
+func slip39Decrypt(encrypted []byte, passphrase []byte, iterationExponent int, identifier int, extendable bool) ([]byte, error) {
+	half := len(encrypted) / 2
+	l := append([]byte{}, encrypted[:half]...)
+	r := append([]byte{}, encrypted[half:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := slip39RoundCount - 1; i >= 0; i-- {
+		f := slip39RoundFunction(i, passphrase, iterationExponent, salt, r)
+		l, r = r, xorBytes(l, f)
+	}
+	return append(r, l...), nil
+}
+
+func slip39Salt(identifier int, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	return append(append([]byte{}, slip39CustomizationString...), byte(identifier>>8), byte(identifier))
+}
+
+func slip39RoundFunction(round int, passphrase []byte, iterationExponent int, salt []byte, r []byte) []byte {
+	iterations := (slip39BaseIterationCount << uint(iterationExponent)) / slip39RoundCount
+	password := append([]byte{byte(round)}, passphrase...)
+	return pbkdf2.Key(password, append(append([]byte{}, salt...), r...), iterations, len(r), sha256.New)
+}
+
+func xorBytes(a []byte, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// slip39SplitSecret splits a secret into shareCount shares, any threshold of which recover it. Shares at x = 254 and
+// 255 carry a digest and the secret, so a wrong recovery is detected.
+func slip39SplitSecret(threshold int, shareCount int, secret []byte) ([]slip39RawShare, error) {
+	if threshold < 1 || threshold > shareCount || shareCount > slip39MaxShareCount {
+		return nil, ErrInvalidSLIP39Config
+	}
+
+	shares := make([]slip39RawShare, 0, shareCount)
+	if threshold == 1 {
+		for i := 0; i < shareCount; i++ {
+			shares = append(shares, slip39RawShare{x: byte(i), value: append([]byte{}, secret...)})
+		}
+		return shares, nil
+	}
+
+	randomShareCount := threshold - 2
+	for i := 0; i < randomShareCount; i++ {
+		value := make([]byte, len(secret))
+		if _, err := io.ReadFull(entropyReader, value); err != nil {
+			return nil, err
+		}
+		shares = append(shares, slip39RawShare{x: byte(i), value: value})
+	}
+
+	randomPart := make([]byte, len(secret)-slip39DigestSize)
+	if _, err := io.ReadFull(entropyReader, randomPart); err != nil {
+		return nil, err
+	}
+	digest := slip39Digest(randomPart, secret)
+
+	baseShares := append(append([]slip39RawShare{}, shares...),
+		slip39RawShare{x: slip39DigestIndex, value: append(digest, randomPart...)},
+		slip39RawShare{x: slip39SecretIndex, value: secret},
+	)
+	for i := randomShareCount; i < shareCount; i++ {
+		shares = append(shares, slip39RawShare{x: byte(i), value: slip39Interpolate(baseShares, byte(i))})
+	}
+	return shares, nil
+}
+
+func slip39RecoverSecret(threshold int, shares []slip39RawShare) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].value, nil
+	}
+
+	secret := slip39Interpolate(shares, slip39SecretIndex)
+	digestShare := slip39Interpolate(shares, slip39DigestIndex)
+	if !hmac.Equal(digestShare[:slip39DigestSize], slip39Digest(digestShare[slip39DigestSize:], secret)) {
+		return nil, ErrMismatchedSLIP39Shares
+	}
+	return secret, nil
+}
+
+func slip39Digest(randomPart []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestSize]
+}
+
+// slip39Interpolate evaluates at x the polynomial over GF(256) through the shares, using Lagrange interpolation.
+func slip39Interpolate(shares []slip39RawShare, x byte) []byte {
+	for _, share := range shares {
+		if share.x == x {
+			return append([]byte{}, share.value...)
+		}
+	}
+
+	expTable, logTable := gf256Exp, gf256Log
+
+	logProd := 0
+	for _, share := range shares {
+		logProd += int(logTable[share.x^x])
+	}
+
+	result := make([]byte, len(shares[0].value))
+	for _, share := range shares {
+		logBasis := logProd - int(logTable[share.x^x])
+		for _, other := range shares {
+			logBasis -= int(logTable[share.x^other.x])
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+
+		for i, y := range share.value {
+			if y != 0 {
+				result[i] ^= expTable[(int(logTable[y])+logBasis)%255]
+			}
+		}
+	}
+	return result
+}
+
+// gf256Tables returns exponent and logarithm tables for GF(256) with the Rijndael polynomial, with generator x + 1.
+func gf256Tables() ([255]byte, [256]byte) {
+	var expTable [255]byte
+	var logTable [256]byte
+	poly := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(poly)
+		logTable[poly] = byte(i)
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+	return expTable, logTable
+}
@@ -0,0 +1,414 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// published SLIP-39 test vectors (https://github.com/trezor/python-shamir-mnemonic/blob/master/vectors.json), all with
+// passphrase "TREZOR"
+var slip39TestVectors = []struct {
+	description  string
+	mnemonics    []string
+	masterSecret string
+}{
+	{
+		"Valid mnemonic without sharing (128 bits)",
+		[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+		"bb54aac4b89dc868ba37d9cc21b2cece",
+	},
+	{
+		"Basic sharing 2-of-3 (128 bits)",
+		[]string{
+			"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+			"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+		},
+		"b43ceb7e57a0ea8766221624d01b0864",
+	},
+	{
+		"Threshold number of groups and members in each group (128 bits, case 1)",
+		[]string{
+			"eraser senior beard romp adorn nuclear spill corner cradle style ancient family general leader ambition exchange unusual garlic promise voice",
+			"eraser senior ceramic snake clay various huge numb argue hesitate auction category timber browser greatest hanger petition script leaf pickup",
+			"eraser senior ceramic shaft dynamic become junior wrist silver peasant force math alto coal amazing segment yelp velvet image paces",
+			"eraser senior ceramic round column hawk trust auction smug shame alive greatest sheriff living perfect corner chest sled fumes adequate",
+			"eraser senior decision smug corner ruin rescue cubic angel tackle skin skunk program roster trash rumor slush angel flea amazing",
+		},
+		"7c3397a292a5941682d7a4ae2d898d11",
+	},
+	{
+		"Threshold number of groups and members in each group (128 bits, case 2)",
+		[]string{
+			"eraser senior decision scared cargo theory device idea deliver modify curly include pancake both news skin realize vitamins away join",
+			"eraser senior decision roster beard treat identify grumpy salt index fake aviation theater cubic bike cause research dragon emphasis counter",
+			"eraser senior ceramic snake clay various huge numb argue hesitate auction category timber browser greatest hanger petition script leaf pickup",
+			"eraser senior ceramic shaft dynamic become junior wrist silver peasant force math alto coal amazing segment yelp velvet image paces",
+			"eraser senior ceramic round column hawk trust auction smug shame alive greatest sheriff living perfect corner chest sled fumes adequate",
+		},
+		"7c3397a292a5941682d7a4ae2d898d11",
+	},
+	{
+		"Threshold number of groups and members in each group (128 bits, case 3)",
+		[]string{
+			"eraser senior beard romp adorn nuclear spill corner cradle style ancient family general leader ambition exchange unusual garlic promise voice",
+			"eraser senior acrobat romp bishop medical gesture pumps secret alive ultimate quarter priest subject class dictate spew material endless market",
+		},
+		"7c3397a292a5941682d7a4ae2d898d11",
+	},
+	{
+		"Valid mnemonic without sharing (256 bits)",
+		[]string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+		"989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+	},
+	{
+		"Basic sharing 2-of-3 (256 bits)",
+		[]string{
+			"humidity disease academic always aluminum jewelry energy woman receiver strategy amuse duckling lying evidence network walnut tactics forget hairy rebound impulse brother survive clothes stadium mailman rival ocean reward venture always armed unwrap",
+			"humidity disease academic agency actress jacket gross physics cylinder solution fake mortgage benefit public busy prepare sharp friar change work slow purchase ruler again tricycle involve viral wireless mixture anatomy desert cargo upgrade",
+		},
+		"c938b319067687e990e05e0da0ecce1278f75ff58d9853f19dcaeed5de104aae",
+	},
+	{
+		"Valid extendable mnemonic without sharing (128 bits)",
+		[]string{"testify swimming academic academic column loyalty smear include exotic bedroom exotic wrist lobe cover grief golden smart junior estimate learn"},
+		"1679b4516e0ee5954351d288a838f45e",
+	},
+	{
+		"Valid extendable mnemonic without sharing (256 bits)",
+		[]string{"impulse calcium academic academic alcohol sugar lyrics pajamas column facility finance tension extend space birthday rainbow swimming purple syndrome facility trial warn duration snapshot shadow hormone rhyme public spine counter easy hawk album"},
+		"8340611602fe91af634a5f4608377b5235fa2d757c51d720c0c7656249a3035f",
+	},
+	{
+		"Basic sharing 2-of-3, extendable (256 bits)",
+		[]string{
+			"western apart academic always artist resident briefing sugar woman oven coding club ajar merit pecan answer prisoner artist fraction amount desktop mild false necklace muscle photo wealthy alpha category unwrap spew losing making",
+			"western apart academic acid answer ancient auction flip image penalty oasis beaver multiple thunder problem switch alive heat inherit superior teaspoon explain blanket pencil numb lend punish endless aunt garlic humidity kidney observe",
+		},
+		"8dc652d6d6cd370d8c963141f6d79ba440300f25c467302c1d966bff8f62300d",
+	},
+}
+
+// published SLIP-39 vectors that must fail, with the error each fails with
+var slip39InvalidTestVectors = []struct {
+	description string
+	mnemonics   []string
+	err         error
+}{
+	{
+		"Mnemonic with invalid checksum (128 bits)",
+		[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+		ErrInvalidSLIP39Share,
+	},
+	{
+		"Mnemonic with invalid padding (128 bits)",
+		[]string{"duckling enlarge academic academic email result length solution fridge kidney coal piece deal husband erode duke ajar music cargo fitness"},
+		ErrInvalidSLIP39Share,
+	},
+	{
+		"Basic sharing 2-of-3, insufficient number of shares (128 bits)",
+		[]string{"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed"},
+		ErrInsufficientSLIP39Shares,
+	},
+	{
+		"Mnemonics with different identifiers (128 bits)",
+		[]string{
+			"adequate smoking academic acid debut wine petition glen cluster slow rhyme slow simple epidemic rumor junk tracks treat olympic tolerate",
+			"adequate stay academic agency agency formal party ting frequent learn upstairs remember smear leaf damage anatomy ladle market hush corner",
+		},
+		ErrMismatchedSLIP39Shares,
+	},
+	{
+		"Mnemonics with different iteration exponents (128 bits)",
+		[]string{
+			"peasant leaves academic acid desert exact olympic math alive axle trial tackle drug deny decent smear dominant desert bucket remind",
+			"peasant leader academic agency cultural blessing percent network envelope medal junk primary human pumps jacket fragment payroll ticket evoke voice",
+		},
+		ErrMismatchedSLIP39Shares,
+	},
+	{
+		"Mnemonics with mismatching group thresholds (128 bits)",
+		[]string{
+			"liberty category beard echo animal fawn temple briefing math username various wolf aviation fancy visual holy thunder yelp helpful payment",
+			"liberty category beard email beyond should fancy romp founder easel pink holy hairy romp loyalty material victim owner toxic custody",
+			"liberty category academic easy being hazard crush diminish oral lizard reaction cluster force dilemma deploy force club veteran expect photo",
+		},
+		ErrMismatchedSLIP39Shares,
+	},
+	{
+		"Mnemonics with greater group threshold than group counts (128 bits)",
+		[]string{
+			"music husband acrobat acid artist finance center either graduate swimming object bike medical clothes station aspect spider maiden bulb welcome",
+			"music husband acrobat agency advance hunting bike corner density careful material civil evil tactics remind hawk discuss hobo voice rainbow",
+			"music husband beard academic black tricycle clock mayor estimate level photo episode exclude ecology papa source amazing salt verify divorce",
+		},
+		ErrInvalidSLIP39Share,
+	},
+	{
+		"Mnemonics with duplicate member indices (128 bits)",
+		[]string{
+			"device stay academic always dive coal antenna adult black exceed stadium herald advance soldier busy dryer daughter evaluate minister laser",
+			"device stay academic always dwarf afraid robin gravity crunch adjust soul branch walnut coastal dream costume scholar mortgage mountain pumps",
+		},
+		ErrMismatchedSLIP39Shares,
+	},
+	{
+		"Mnemonics with mismatching member thresholds (128 bits)",
+		[]string{
+			"hour painting academic academic device formal evoke guitar random modern justice filter withdraw trouble identify mailman insect general cover oven",
+			"hour painting academic agency artist again daisy capital beaver fiber much enjoy suitable symbolic identify photo editor romp float echo",
+		},
+		ErrMismatchedSLIP39Shares,
+	},
+	{
+		"Mnemonics giving an invalid digest (128 bits)",
+		[]string{
+			"guilt walnut academic acid deliver remove equip listen vampire tactics nylon rhythm failure husband fatigue alive blind enemy teaspoon rebound",
+			"guilt walnut academic agency brave hamster hobo declare herd taste alpha slim criminal mild arcade formal romp branch pink ambition",
+		},
+		ErrMismatchedSLIP39Shares,
+	},
+	{
+		"Insufficient number of groups (128 bits, case 1)",
+		[]string{"eraser senior beard romp adorn nuclear spill corner cradle style ancient family general leader ambition exchange unusual garlic promise voice"},
+		ErrInsufficientSLIP39Shares,
+	},
+	{
+		"Insufficient number of groups (128 bits, case 2)",
+		[]string{
+			"eraser senior decision scared cargo theory device idea deliver modify curly include pancake both news skin realize vitamins away join",
+			"eraser senior decision roster beard treat identify grumpy salt index fake aviation theater cubic bike cause research dragon emphasis counter",
+		},
+		ErrInsufficientSLIP39Shares,
+	},
+	{
+		"Mnemonic with invalid checksum (256 bits)",
+		[]string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect lunar"},
+		ErrInvalidSLIP39Share,
+	},
+	{
+		"Basic sharing 2-of-3, insufficient number of shares (256 bits)",
+		[]string{"humidity disease academic always aluminum jewelry energy woman receiver strategy amuse duckling lying evidence network walnut tactics forget hairy rebound impulse brother survive clothes stadium mailman rival ocean reward venture always armed unwrap"},
+		ErrInsufficientSLIP39Shares,
+	},
+	{
+		"Mnemonic with invalid master secret length",
+		[]string{"fraction necklace academic academic award teammate mouse regular testify coding building member verdict purchase blind camera duration email prepare spirit quarter"},
+		ErrInvalidSLIP39Share,
+	},
+}
+
+func slip39MnemonicsForTest(mnemonics ...string) *SLIP39Mnemonics {
+	list := NewSLIP39Mnemonics()
+	for _, mnemonic := range mnemonics {
+		list.Add(mnemonic)
+	}
+	return list
+}
+
+func TestCombineSLIP39Shares_TestVectors(t *testing.T) {
+	for _, vector := range slip39TestVectors {
+		secret, err := CombineSLIP39Shares(slip39MnemonicsForTest(vector.mnemonics...), "TREZOR")
+		assert.Nil(t, err, vector.description)
+		assert.Equal(t, vector.masterSecret, hex.EncodeToString(secret), vector.description)
+	}
+}
+
+func TestCombineSLIP39Shares_InvalidTestVectors(t *testing.T) {
+	for _, vector := range slip39InvalidTestVectors {
+		secret, err := CombineSLIP39Shares(slip39MnemonicsForTest(vector.mnemonics...), "TREZOR")
+		assert.Equal(t, vector.err, err, vector.description)
+		assert.Nil(t, secret, vector.description)
+	}
+}
+
+func TestCombineSLIP39Shares_GroupIndexOutOfRange(t *testing.T) {
+	// re-encode a single group share with group index 1, which a 1 group set cannot have
+	share, err := decodeSLIP39Share(slip39TestVectors[0].mnemonics[0])
+	assert.Nil(t, err)
+	share.groupIndex = 1
+
+	_, err = decodeSLIP39Share(share.mnemonic())
+	assert.Equal(t, ErrInvalidSLIP39Share, err)
+	_, err = CombineSLIP39Shares(slip39MnemonicsForTest(share.mnemonic()), "TREZOR")
+	assert.Equal(t, ErrInvalidSLIP39Share, err)
+}
+
+func TestCombineSLIP39Shares_InsufficientShares(t *testing.T) {
+	_, err := CombineSLIP39Shares(slip39MnemonicsForTest(slip39TestVectors[1].mnemonics[0]), "TREZOR")
+	assert.Equal(t, ErrInsufficientSLIP39Shares, err)
+
+	_, err = CombineSLIP39Shares(NewSLIP39Mnemonics(), "TREZOR")
+	assert.Equal(t, ErrInsufficientSLIP39Shares, err)
+}
+
+func TestCombineSLIP39Shares_MismatchedSets(t *testing.T) {
+	shares := slip39MnemonicsForTest(slip39TestVectors[0].mnemonics[0], slip39TestVectors[1].mnemonics[0])
+	_, err := CombineSLIP39Shares(shares, "TREZOR")
+	assert.Equal(t, ErrMismatchedSLIP39Shares, err)
+}
+
+func TestCombineSLIP39Shares_AbbreviatedWords(t *testing.T) {
+	words := strings.Fields(slip39TestVectors[0].mnemonics[0])
+	for i, word := range words {
+		if len(word) > 4 {
+			words[i] = word[:4]
+		}
+	}
+
+	secret, err := CombineSLIP39Shares(slip39MnemonicsForTest(strings.Join(words, " ")), "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, slip39TestVectors[0].masterSecret, hex.EncodeToString(secret))
+}
+
+func TestSplitSeedSLIP39_GroupRoundTrip(t *testing.T) {
+	secret, _ := hex.DecodeString("bb54aac4b89dc868ba37d9cc21b2cece")
+	config := NewSLIP39Config(2)
+	config.Passphrase = "TREZOR"
+	config.AddGroup(1, 1)
+	config.AddGroup(2, 3)
+	config.AddGroup(3, 5)
+
+	set, err := SplitSeedSLIP39(secret, config)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, set.GroupCount())
+	assert.Equal(t, 1, set.GroupAtIndex(0).Count())
+	assert.Equal(t, 3, set.GroupAtIndex(1).Count())
+	assert.Equal(t, 5, set.GroupAtIndex(2).Count())
+	assert.Nil(t, set.GroupAtIndex(3))
+	assert.Equal(t, 20, len(strings.Fields(set.GroupAtIndex(0).MnemonicAtIndex(0))))
+
+	// groups 0 and 1
+	shares := slip39MnemonicsForTest(set.GroupAtIndex(0).MnemonicAtIndex(0), set.GroupAtIndex(1).MnemonicAtIndex(2), set.GroupAtIndex(1).MnemonicAtIndex(0))
+	recovered, err := CombineSLIP39Shares(shares, "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, recovered)
+
+	// groups 1 and 2, with an extra share from group 2
+	shares = slip39MnemonicsForTest(
+		set.GroupAtIndex(2).MnemonicAtIndex(4), set.GroupAtIndex(2).MnemonicAtIndex(1), set.GroupAtIndex(2).MnemonicAtIndex(3), set.GroupAtIndex(2).MnemonicAtIndex(0),
+		set.GroupAtIndex(1).MnemonicAtIndex(1), set.GroupAtIndex(1).MnemonicAtIndex(2),
+	)
+	recovered, err = CombineSLIP39Shares(shares, "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, recovered)
+
+	// group 0 and an incomplete group 2
+	shares = slip39MnemonicsForTest(set.GroupAtIndex(0).MnemonicAtIndex(0), set.GroupAtIndex(2).MnemonicAtIndex(0), set.GroupAtIndex(2).MnemonicAtIndex(1))
+	_, err = CombineSLIP39Shares(shares, "TREZOR")
+	assert.Equal(t, ErrInsufficientSLIP39Shares, err)
+
+	// the passphrase is not checked, a different one yields a different secret
+	shares = slip39MnemonicsForTest(set.GroupAtIndex(0).MnemonicAtIndex(0), set.GroupAtIndex(1).MnemonicAtIndex(0), set.GroupAtIndex(1).MnemonicAtIndex(1))
+	recovered, err = CombineSLIP39Shares(shares, "")
+	assert.Nil(t, err)
+	assert.NotEqual(t, secret, recovered)
+}
+
+func TestSplitSeedSLIP39_NonExtendable(t *testing.T) {
+	secret, _ := hex.DecodeString("989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92")
+	config := NewSLIP39Config(1)
+	config.Extendable = false
+	config.IterationExponent = 1
+	config.AddGroup(3, 5)
+
+	set, err := SplitSeedSLIP39(secret, config)
+	assert.Nil(t, err)
+	assert.Equal(t, 33, len(strings.Fields(set.GroupAtIndex(0).MnemonicAtIndex(0))))
+
+	group := set.GroupAtIndex(0)
+	recovered, err := CombineSLIP39Shares(slip39MnemonicsForTest(group.MnemonicAtIndex(3), group.MnemonicAtIndex(0), group.MnemonicAtIndex(2)), "")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, recovered)
+
+	_, err = CombineSLIP39Shares(slip39MnemonicsForTest(group.MnemonicAtIndex(3), group.MnemonicAtIndex(0)), "")
+	assert.Equal(t, ErrInsufficientSLIP39Shares, err)
+}
+
+func TestSplitSeedSLIP39_InvalidConfig(t *testing.T) {
+	secret := make([]byte, 16)
+
+	config := NewSLIP39Config(2)
+	config.AddGroup(2, 3)
+	_, err := SplitSeedSLIP39(secret, config)
+	assert.Equal(t, ErrInvalidSLIP39Config, err)
+
+	config = NewSLIP39Config(1)
+	config.AddGroup(1, 3)
+	_, err = SplitSeedSLIP39(secret, config)
+	assert.Equal(t, ErrInvalidSLIP39Config, err)
+
+	config = NewSLIP39Config(1)
+	config.AddGroup(4, 3)
+	_, err = SplitSeedSLIP39(secret, config)
+	assert.Equal(t, ErrInvalidSLIP39Config, err)
+
+	config = NewSLIP39Config(1)
+	config.AddGroup(2, 3)
+	_, err = SplitSeedSLIP39(make([]byte, 15), config)
+	assert.Equal(t, ErrInvalidSLIP39Config, err)
+
+	config.Passphrase = "TRÉZOR"
+	_, err = SplitSeedSLIP39(secret, config)
+	assert.Equal(t, ErrInvalidSLIP39Passphrase, err)
+
+	_, err = SplitSeedSLIP39(secret, nil)
+	assert.Equal(t, ErrInvalidSLIP39Config, err)
+}
+
+func TestNewHDWalletFromSLIP39Shares_RestoresBIP39Wallet(t *testing.T) {
+	config := NewSLIP39Config(1)
+	config.AddGroup(2, 3)
+
+	set, err := SplitMnemonicSLIP39(w, "", config)
+	assert.Nil(t, err)
+
+	group := set.GroupAtIndex(0)
+	wallet, err := NewHDWalletFromSLIP39Shares(slip39MnemonicsForTest(group.MnemonicAtIndex(0), group.MnemonicAtIndex(2)), "", BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+
+	expected := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	assert.Equal(t, expected.masterPrivateKey.String(), wallet.masterPrivateKey.String())
+	assert.Equal(t, "", wallet.WalletWords)
+
+	_, err = SplitMnemonicSLIP39("abandon abandon abandon", "", config)
+	assert.Equal(t, ErrInvalidMnemonic, err)
+}
+
+func TestSplitMnemonicSLIP39_NonCanonicalWords(t *testing.T) {
+	config := NewSLIP39Config(1)
+	config.AddGroup(2, 3)
+	words := "Abandon  " + strings.Replace(strings.TrimPrefix(w, "abandon "), " ", "\t", 2) + "\n"
+
+	set, err := SplitMnemonicSLIP39(words, "TREZOR", config)
+	assert.Nil(t, err)
+
+	group := set.GroupAtIndex(0)
+	wallet, err := NewHDWalletFromSLIP39Shares(slip39MnemonicsForTest(group.MnemonicAtIndex(1), group.MnemonicAtIndex(2)), "", BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+
+	expected, err := NewHDWalletFromMnemonic(words, "TREZOR", BaseCoinBip84MainNet)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.masterPrivateKey.String(), wallet.masterPrivateKey.String())
+	assert.Equal(t, expected.masterPrivateKey.String(), NewHDWalletFromWordsWithPassphrase(words, "TREZOR", BaseCoinBip84MainNet).masterPrivateKey.String())
+
+	fingerprint, err := wallet.MasterFingerprint()
+	assert.Nil(t, err)
+	expectedFingerprint, err := expected.MasterFingerprint()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedFingerprint, fingerprint)
+}
+
+func TestSLIP39Wordlist(t *testing.T) {
+	assert.Equal(t, 1024, len(slip39Wordlist))
+	assert.True(t, sort.StringsAreSorted(slip39Wordlist))
+
+	prefixes := make(map[string]bool)
+	for _, word := range slip39Wordlist {
+		assert.False(t, prefixes[word[:4]], word)
+		prefixes[word[:4]] = true
+	}
+}
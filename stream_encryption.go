@@ -0,0 +1,210 @@
+package cnlib
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// A stream is encrypted in fixed-size chunks, each sealed with AES-256-GCM under a key derived from the same ECDH secret as
+// the v3 payload and a random per-stream salt. Layout:
+//
+//	version (1) | options (1) | chunk size (4, big endian) | salt (32) | sender uncompressed public key (65)
+//	chunk 0 | chunk 1 | ... | final chunk
+//
+// Every chunk but the last holds exactly chunk size bytes of plain text plus a 16 byte tag; the final chunk may be shorter
+// (or empty). Each chunk's nonce is its big endian counter followed by a final-chunk flag, and the header is authenticated
+// with every chunk, so reordered, dropped, truncated or extended streams fail to decrypt.
+const (
+	streamVersion          = byte(5)
+	streamSaltSize         = 32
+	streamHeaderSize       = 1 + 1 + 4 + streamSaltSize + 65
+	streamNonceSize        = 12
+	streamTagSize          = 16
+	streamDefaultChunkSize = 64 * 1024
+	streamMaxChunkSize     = 16 * 1024 * 1024
+)
+
+var (
+	// ErrInvalidChunkSize describes an error in which a stream chunk size is zero or too large.
+	ErrInvalidChunkSize = errors.New("invalid stream chunk size")
+
+	// ErrStreamTruncated describes an error in which a stream ended before its final chunk.
+	ErrStreamTruncated = errors.New("stream truncated")
+
+	// ErrInvalidStreamChunk describes an error in which a stream chunk fails authentication.
+	ErrInvalidStreamChunk = errors.New("invalid stream chunk")
+)
+
+// encryptStream reads plain text from src until EOF, and writes the encrypted stream to dst.
+func encryptStream(dst io.Writer, src io.Reader, chunkSize int, privateKey *btcec.PrivateKey, publicKey *btcec.PublicKey) error {
+	if chunkSize <= 0 || chunkSize > streamMaxChunkSize {
+		return ErrInvalidChunkSize
+	}
+
+	salt, err := randBytes(streamSaltSize)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, streamHeaderSize)
+	header = append(header, streamVersion, byte(0))
+	header = append(header, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(header[2:6], uint32(chunkSize))
+	header = append(header, salt...)
+	header = append(header, privateKey.PubKey().SerializeUncompressed()...)
+
+	aead, err := newStreamCipher(privateKey, publicKey, salt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(src)
+	plainText := make([]byte, chunkSize)
+	sealed := make([]byte, 0, chunkSize+streamTagSize)
+	nonce := make([]byte, streamNonceSize)
+
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, plainText)
+		final := false
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			final = true
+		} else if err != nil {
+			return err
+		} else if _, err := reader.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+
+		streamNonce(nonce, counter, final)
+		sealed = aead.Seal(sealed[:0], nonce, plainText[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+
+		if final {
+			return nil
+		}
+	}
+}
+
+// decryptStream reads an encrypted stream from src, and writes the plain text to dst one authenticated chunk at a time.
+// If an error is returned, anything already written to dst must be discarded.
+func decryptStream(dst io.Writer, src io.Reader, privateKey *btcec.PrivateKey) (*btcec.PublicKey, error) {
+	reader := bufio.NewReader(src)
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInsufficientData
+		}
+		return nil, err
+	}
+
+	if header[0] != streamVersion {
+		return nil, ErrInvalidPayloadVersion
+	}
+	if header[1] != byte(0) {
+		return nil, ErrInvalidPayloadOption
+	}
+
+	chunkSize := int(binary.BigEndian.Uint32(header[2:6]))
+	if chunkSize <= 0 || chunkSize > streamMaxChunkSize {
+		return nil, ErrInvalidChunkSize
+	}
+
+	salt := header[6 : 6+streamSaltSize]
+	sender, err := btcec.ParsePubKey(header[6+streamSaltSize:], btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newStreamCipher(privateKey, sender, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, chunkSize+streamTagSize)
+	plainText := make([]byte, 0, chunkSize)
+	nonce := make([]byte, streamNonceSize)
+
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, sealed)
+		final := false
+		if err == io.EOF {
+			return nil, ErrStreamTruncated
+		} else if err == io.ErrUnexpectedEOF {
+			final = true
+		} else if err != nil {
+			return nil, err
+		} else if _, err := reader.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return nil, err
+		}
+
+		if n < streamTagSize {
+			return nil, ErrStreamTruncated
+		}
+
+		streamNonce(nonce, counter, final)
+		plainText, err = aead.Open(plainText[:0], nonce, sealed[:n], header)
+		if err != nil {
+			// a full chunk that authenticates as non-final means the stream was cut at a chunk boundary
+			streamNonce(nonce, counter, false)
+			if _, openErr := aead.Open(nil, nonce, sealed[:n], header); final && openErr == nil {
+				return nil, ErrStreamTruncated
+			}
+			return nil, ErrInvalidStreamChunk
+		}
+
+		if _, err := dst.Write(plainText); err != nil {
+			return nil, err
+		}
+
+		if final {
+			return sender, nil
+		}
+	}
+}
+
+// newStreamCipher derives a per-stream AES-256-GCM cipher from the ECDH secret and the stream salt.
+func newStreamCipher(privateKey *btcec.PrivateKey, publicKey *btcec.PublicKey, salt []byte) (cipher.AEAD, error) {
+	secret := generateSharedSecretRFC4753(privateKey, publicKey)
+	keyData := sha512.Sum512(secret)
+
+	mac := hmac.New(sha256.New, keyData[:32])
+	if _, err := mac.Write(salt); err != nil {
+		return nil, errors.New("failed to write stream salt")
+	}
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// streamNonce fills nonce with the chunk counter and final-chunk flag.
+func streamNonce(nonce []byte, counter uint64, final bool) {
+	binary.BigEndian.PutUint64(nonce[:8], counter)
+	nonce[8], nonce[9], nonce[10] = 0, 0, 0
+	nonce[11] = 0
+	if final {
+		nonce[11] = 1
+	}
+}
@@ -0,0 +1,162 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptTestStream(t *testing.T, plainText []byte, chunkSize int) []byte {
+	alice, bob := encryptionTestKeys(t)
+	var buf bytes.Buffer
+	err := encryptStream(&buf, bytes.NewReader(plainText), chunkSize, alice, bob.PubKey())
+	assert.Nil(t, err)
+	return buf.Bytes()
+}
+
+func TestEncryptStream_RoundTrip(t *testing.T) {
+	alice, bob := encryptionTestKeys(t)
+	chunkSize := 16
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		plainText := make([]byte, size)
+		_, err := rand.Read(plainText)
+		assert.Nil(t, err)
+
+		enc := encryptTestStream(t, plainText, chunkSize)
+		chunks := (size + chunkSize - 1) / chunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		assert.Equal(t, streamHeaderSize+size+chunks*streamTagSize, len(enc))
+
+		var out bytes.Buffer
+		sender, err := decryptStream(&out, bytes.NewReader(enc), bob)
+		assert.Nil(t, err)
+		assert.True(t, sender.IsEqual(alice.PubKey()))
+		assert.Equal(t, size, out.Len())
+		assert.True(t, bytes.Equal(plainText, out.Bytes()))
+	}
+}
+
+func TestEncryptStream_WalletEndToEnd(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	aliceCPK, err := aliceWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+	bobCPK, err := bobWallet.CoinNinjaVerificationKeyHexString()
+	assert.Nil(t, err)
+
+	// a few chunks at the default chunk size
+	plainText := make([]byte, 3*streamDefaultChunkSize+123)
+	_, err = rand.Read(plainText)
+	assert.Nil(t, err)
+
+	var enc bytes.Buffer
+	err = aliceWallet.EncryptStream(&enc, bytes.NewReader(plainText), bobCPK)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	sender, err := bobWallet.DecryptStream(&out, &enc)
+	assert.Nil(t, err)
+	assert.Equal(t, aliceCPK, sender)
+	assert.Equal(t, plainText, out.Bytes())
+}
+
+func TestEncryptStream_DecryptWithKeyFromDerivationPath(t *testing.T) {
+	aliceWallet := NewHDWalletFromWords(w, BaseCoinBip84MainNet)
+	bobWallet := NewHDWalletFromWords(bobWords, BaseCoinBip84MainNet)
+	bobAddr, err := bobWallet.ReceiveAddressForIndex(0)
+	assert.Nil(t, err)
+
+	var enc bytes.Buffer
+	err = aliceWallet.EncryptStream(&enc, bytes.NewReader([]byte("receipt.png")), bobAddr.UncompressedPublicKey)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	_, err = bobWallet.DecryptStreamWithKeyFromDerivationPath(bobAddr.DerivationPath, &out, &enc)
+	assert.Nil(t, err)
+	assert.Equal(t, "receipt.png", out.String())
+}
+
+func TestDecryptStream_ReorderedChunks(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+	chunkSize := 16
+	enc := encryptTestStream(t, make([]byte, 3*chunkSize), chunkSize)
+
+	sealedSize := chunkSize + streamTagSize
+	first := streamHeaderSize
+	second := first + sealedSize
+	reordered := append([]byte{}, enc[:first]...)
+	reordered = append(reordered, enc[second:second+sealedSize]...)
+	reordered = append(reordered, enc[first:second]...)
+	reordered = append(reordered, enc[second+sealedSize:]...)
+
+	var out bytes.Buffer
+	_, err := decryptStream(&out, bytes.NewReader(reordered), bob)
+	assert.Equal(t, ErrInvalidStreamChunk, err)
+}
+
+func TestDecryptStream_TruncatedAtChunkBoundary(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+	chunkSize := 16
+	enc := encryptTestStream(t, make([]byte, 3*chunkSize), chunkSize)
+
+	// drop the final chunk, and then the last two chunks
+	sealedSize := chunkSize + streamTagSize
+	for _, drop := range []int{sealedSize, 2 * sealedSize} {
+		var out bytes.Buffer
+		_, err := decryptStream(&out, bytes.NewReader(enc[:len(enc)-drop]), bob)
+		assert.Equal(t, ErrStreamTruncated, err)
+	}
+
+	var out bytes.Buffer
+	_, err := decryptStream(&out, bytes.NewReader(enc[:streamHeaderSize]), bob)
+	assert.Equal(t, ErrStreamTruncated, err)
+}
+
+func TestDecryptStream_TruncatedMidChunk(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+	chunkSize := 16
+	enc := encryptTestStream(t, make([]byte, 40), chunkSize)
+
+	var out bytes.Buffer
+	_, err := decryptStream(&out, bytes.NewReader(enc[:len(enc)-3]), bob)
+	assert.Equal(t, ErrInvalidStreamChunk, err)
+}
+
+func TestDecryptStream_TrailingData(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+	enc := encryptTestStream(t, make([]byte, 40), 16)
+
+	var out bytes.Buffer
+	_, err := decryptStream(&out, bytes.NewReader(append(enc, 0x00)), bob)
+	assert.Equal(t, ErrInvalidStreamChunk, err)
+}
+
+func TestDecryptStream_InvalidHeader(t *testing.T) {
+	_, bob := encryptionTestKeys(t)
+	enc := encryptTestStream(t, []byte("hey dude"), 16)
+
+	var out bytes.Buffer
+	_, err := decryptStream(&out, bytes.NewReader(enc[:10]), bob)
+	assert.Equal(t, ErrInsufficientData, err)
+
+	badVersion := append([]byte{}, enc...)
+	badVersion[0] = payloadVersion
+	_, err = decryptStream(&out, bytes.NewReader(badVersion), bob)
+	assert.Equal(t, ErrInvalidPayloadVersion, err)
+
+	badChunkSize := append([]byte{}, enc...)
+	copy(badChunkSize[2:6], []byte{0, 0, 0, 0})
+	_, err = decryptStream(&out, bytes.NewReader(badChunkSize), bob)
+	assert.Equal(t, ErrInvalidChunkSize, err)
+
+	// the header is authenticated with every chunk
+	badSalt := append([]byte{}, enc...)
+	badSalt[6] ^= 0x01
+	_, err = decryptStream(&out, bytes.NewReader(badSalt), bob)
+	assert.Equal(t, ErrInvalidStreamChunk, err)
+}
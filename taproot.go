@@ -0,0 +1,205 @@
+package cnlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+	secp256k1 "gitlab.com/yawning/secp256k1-voi"
+	"gitlab.com/yawning/secp256k1-voi/secec"
+	"gitlab.com/yawning/secp256k1-voi/secec/bitcoin"
+)
+
+// The btcd/btcutil versions lnd v0.8.2 builds against predate taproot, so this file carries what BIP86 key-path outputs
+// need on top of them: segwit address handling with btcd/btcutil's bech32m (BIP350) codec, the BIP341 key tweak, and
+// BIP340 schnorr signatures, with the curve arithmetic left to secp256k1-voi.
+
+const (
+	taprootWitnessVersion = 1
+	taprootProgramSize    = 32
+	schnorrSignatureSize  = 64
+)
+
+var (
+	// ErrInvalidSegwitAddress describes an error in which a bech32/bech32m address cannot be decoded.
+	ErrInvalidSegwitAddress = errors.New("invalid segwit address")
+
+	// ErrInvalidSchnorrSignature describes an error in which a schnorr signature cannot be produced or parsed.
+	ErrInvalidSchnorrSignature = errors.New("invalid schnorr signature")
+
+	// ErrInvalidXOnlyPublicKey describes an error in which a 32 byte x-only public key is not on the curve.
+	ErrInvalidXOnlyPublicKey = errors.New("invalid x-only public key")
+
+	// ErrTaprootSpendingUnsupported describes an error in which a BIP86 address is requested for receiving or spending funds.
+	// Taproot outputs cannot yet be signed by the transaction builder, so BIP86 keys are limited to message signing.
+	ErrTaprootSpendingUnsupported = errors.New("taproot spending is not supported")
+)
+
+/// Addresses
+
+// buildTaprootAddress returns the BIP86 P2TR address for an internal public key.
+func buildTaprootAddress(path *DerivationPath, pubkey *btcec.PublicKey) (string, error) {
+	outputKey, err := taprootOutputKey(pubkey)
+	if err != nil {
+		return "", err
+	}
+	return encodeSegwitAddress(path.BaseCoin.defaultNetParams().Bech32HRPSegwit, taprootWitnessVersion, outputKey)
+}
+
+// taprootScript returns the witness v1 output script for an x-only output key.
+func taprootScript(outputKey []byte) []byte {
+	script := []byte{0x51, taprootProgramSize}
+	return append(script, outputKey...)
+}
+
+// encodeSegwitAddress encodes a witness program as a bech32 (v0) or bech32m (v1+) address.
+func encodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	converted, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	data := append([]byte{version}, converted...)
+	if version == 0 {
+		return bech32.Encode(hrp, data)
+	}
+	return bech32.EncodeM(hrp, data)
+}
+
+// decodeSegwitAddress decodes a bech32 (v0) or bech32m (v1+) address, returning its hrp, witness version and program.
+func decodeSegwitAddress(addr string) (string, byte, []byte, error) {
+	hrp, data, checksumVersion, err := bech32.DecodeGeneric(addr)
+	if err != nil || len(data) == 0 {
+		return "", 0, nil, ErrInvalidSegwitAddress
+	}
+
+	version := data[0]
+	if version > 16 || (version == 0 && checksumVersion != bech32.Version0) || (version != 0 && checksumVersion != bech32.VersionM) {
+		return "", 0, nil, ErrInvalidSegwitAddress
+	}
+
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil || len(program) < 2 || len(program) > 40 {
+		return "", 0, nil, ErrInvalidSegwitAddress
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", 0, nil, ErrInvalidSegwitAddress
+	}
+
+	return hrp, version, program, nil
+}
+
+// decodeTaprootAddress returns the x-only output key and network of a P2TR address.
+func decodeTaprootAddress(addr string) ([]byte, *chaincfg.Params, error) {
+	hrp, version, program, err := decodeSegwitAddress(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version != taprootWitnessVersion || len(program) != taprootProgramSize {
+		return nil, nil, ErrInvalidSegwitAddress
+	}
+	params := netParamsForSegwitHRP(hrp)
+	if params == nil {
+		return nil, nil, ErrInvalidSegwitAddress
+	}
+	return program, params, nil
+}
+
+func netParamsForSegwitHRP(hrp string) *chaincfg.Params {
+	for _, params := range []*chaincfg.Params{&chaincfg.MainNetParams, &chaincfg.TestNet3Params, &chaincfg.RegressionNetParams} {
+		if params.Bech32HRPSegwit == hrp {
+			return params
+		}
+	}
+	return nil
+}
+
+/// Keys
+
+// taggedHash implements the BIP340 tagged hash, sha256(sha256(tag) || sha256(tag) || msg...).
+func taggedHash(tag string, msg ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+// xOnly returns the 32 byte x coordinate of a public key.
+func xOnly(pubkey *btcec.PublicKey) []byte {
+	return pubkey.SerializeCompressed()[1:]
+}
+
+// taprootTweak returns the BIP86 tweak scalar for an x-only internal key, committing to no script tree.
+func taprootTweak(internal []byte) (*secp256k1.Scalar, error) {
+	var tweakBytes [secp256k1.ScalarSize]byte
+	copy(tweakBytes[:], taggedHash("TapTweak", internal))
+	return secp256k1.NewScalarFromCanonicalBytes(&tweakBytes)
+}
+
+// taprootOutputKey tweaks an internal public key into the x-only BIP86 output key.
+func taprootOutputKey(internal *btcec.PublicKey) ([]byte, error) {
+	key, err := bitcoin.NewSchnorrPublicKey(xOnly(internal))
+	if err != nil {
+		return nil, ErrInvalidXOnlyPublicKey
+	}
+	tweak, err := taprootTweak(key.Bytes())
+	if err != nil {
+		return nil, ErrInvalidXOnlyPublicKey
+	}
+
+	point := secp256k1.NewIdentityPoint().ScalarBaseMult(tweak)
+	outputKey, err := bitcoin.NewSchnorrPublicKeyFromPoint(point.Add(point, key.Point()))
+	if err != nil {
+		return nil, ErrInvalidXOnlyPublicKey
+	}
+	return outputKey.Bytes(), nil
+}
+
+// taprootOutputPrivateKey tweaks an internal private key into the signing key for its BIP86 output key.
+func taprootOutputPrivateKey(internal *btcec.PrivateKey) (*bitcoin.SchnorrPrivateKey, error) {
+	key, err := secec.NewPrivateKey(internal.Serialize())
+	if err != nil {
+		return nil, ErrInvalidSchnorrSignature
+	}
+	xBytes, oddY := secp256k1.SplitUncompressedPoint(key.PublicKey().Bytes())
+
+	tweak, err := taprootTweak(xBytes)
+	if err != nil {
+		return nil, ErrInvalidSchnorrSignature
+	}
+
+	d := secp256k1.NewScalar().ConditionalNegate(key.Scalar(), oddY)
+	tweaked, err := bitcoin.NewSchnorrPrivateKey(d.Add(d, tweak).Bytes())
+	if err != nil {
+		return nil, ErrInvalidSchnorrSignature
+	}
+	return tweaked, nil
+}
+
+/// Schnorr signatures
+
+// schnorrSign produces a BIP340 signature of a 32 byte message, using aux as auxiliary randomness.
+func schnorrSign(privateKey *bitcoin.SchnorrPrivateKey, msg []byte, aux []byte) ([]byte, error) {
+	if len(msg) != 32 || len(aux) != 32 {
+		return nil, ErrInvalidSchnorrSignature
+	}
+	return privateKey.Sign(bytes.NewReader(aux), msg, nil)
+}
+
+// schnorrVerify checks a BIP340 signature of a 32 byte message against an x-only public key.
+func schnorrVerify(pubkey []byte, msg []byte, sig []byte) bool {
+	if len(msg) != 32 {
+		return false
+	}
+	key, err := bitcoin.NewSchnorrPublicKey(pubkey)
+	if err != nil {
+		return false
+	}
+	return key.Verify(msg, sig)
+}
@@ -0,0 +1,150 @@
+package cnlib
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/yawning/secp256k1-voi/secec/bitcoin"
+)
+
+func TestTaggedHash_BIP322MessageHash(t *testing.T) {
+	assert.Equal(t, "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1", hex.EncodeToString(bip322MessageHash("")))
+	assert.Equal(t, "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a", hex.EncodeToString(bip322MessageHash("Hello World")))
+}
+
+func TestSchnorrSign_BIP340Vectors(t *testing.T) {
+	vectors := []struct {
+		secretKey, publicKey, aux, msg, sig string
+	}{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			"b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+			"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+	}
+
+	for _, v := range vectors {
+		skBytes, _ := hex.DecodeString(v.secretKey)
+		aux, _ := hex.DecodeString(v.aux)
+		msg, _ := hex.DecodeString(v.msg)
+		pub, _ := hex.DecodeString(v.publicKey)
+		sk, err := bitcoin.NewSchnorrPrivateKey(skBytes)
+		assert.Nil(t, err)
+		assert.Equal(t, v.publicKey, hex.EncodeToString(sk.PublicKey().Bytes()))
+
+		sig, err := schnorrSign(sk, msg, aux)
+		assert.Nil(t, err)
+		assert.Equal(t, v.sig, hex.EncodeToString(sig))
+		assert.True(t, schnorrVerify(pub, msg, sig))
+
+		sig[0] ^= 0x01
+		assert.False(t, schnorrVerify(pub, msg, sig))
+	}
+}
+
+func TestMessageSigningAddressForIndex_BIP86(t *testing.T) {
+	bc := NewBaseCoin(86, 0, 0)
+	wallet := NewHDWalletFromWords(w, bc)
+
+	acctKey, err := wallet.AccountExtendedMasterPublicKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ", acctKey)
+
+	ma, err := wallet.MessageSigningAddressForIndex(0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", ma.Address)
+
+	ma, err = wallet.MessageSigningAddressForIndex(0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh", ma.Address)
+
+	ma, err = wallet.MessageSigningAddressForIndex(1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7", ma.Address)
+
+	xpubWallet, err := NewHDWalletFromAccountExtendedPublicKey(acctKey)
+	assert.Nil(t, err)
+	xpubWallet.UpdateCoin(bc)
+	ma, err = xpubWallet.MessageSigningAddressForIndex(0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", ma.Address)
+}
+
+func TestReceiveAddressForIndex_BIP86Unsupported(t *testing.T) {
+	wallet := NewHDWalletFromWords(w, NewBaseCoin(86, 0, 0))
+
+	ma, err := wallet.ReceiveAddressForIndex(0)
+	assert.Equal(t, ErrTaprootSpendingUnsupported, err)
+	assert.Nil(t, ma)
+
+	ma, err = wallet.ChangeAddressForIndex(0)
+	assert.Equal(t, ErrTaprootSpendingUnsupported, err)
+	assert.Nil(t, ma)
+}
+
+func TestBuildTransactionMetadata_BIP86Unsupported(t *testing.T) {
+	inputPath := NewDerivationPath(NewBaseCoin(86, 0, 0), 0, 0)
+	utxo := NewUTXO("1a08dafe993fdc17fdc661988c88f97a9974013291e759b9b5766b8e97c78f87", 1, 2788424, inputPath, nil, true)
+	data := NewTransactionDataFlatFee("3BgxxADLtnoKu9oytQiiVzYUqvo8weCVy9", BaseCoinBip49MainNet, 13584, 3000, NewDerivationPath(BaseCoinBip49MainNet, 1, 0), 539943)
+	data.AddUTXO(utxo)
+	assert.Nil(t, data.Generate())
+
+	wallet := NewHDWalletFromWords(w, BaseCoinBip49MainNet)
+	meta, err := wallet.BuildTransactionMetadata(data.TransactionData)
+	assert.Equal(t, ErrTaprootSpendingUnsupported, err)
+	assert.Nil(t, meta)
+}
+
+func TestTaprootOutputKey_BIP86(t *testing.T) {
+	bc := NewBaseCoin(86, 0, 0)
+	wallet := NewHDWalletFromWords(w, bc)
+	pub, err := wallet.CompressedPubKeyForPath(NewDerivationPath(bc, 0, 0))
+	assert.Nil(t, err)
+
+	internal, err := btcec.ParsePubKey(pub, btcec.S256())
+	assert.Nil(t, err)
+	assert.Equal(t, "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115", hex.EncodeToString(xOnly(internal)))
+	outputKey, err := taprootOutputKey(internal)
+	assert.Nil(t, err)
+	assert.Equal(t, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c", hex.EncodeToString(outputKey))
+}
+
+func TestDecodeSegwitAddress(t *testing.T) {
+	hrp, version, program, err := decodeSegwitAddress("bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr")
+	assert.Nil(t, err)
+	assert.Equal(t, "bc", hrp)
+	assert.Equal(t, byte(1), version)
+	assert.Equal(t, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c", hex.EncodeToString(program))
+
+	hrp, version, program, err = decodeSegwitAddress("bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu")
+	assert.Nil(t, err)
+	assert.Equal(t, "bc", hrp)
+	assert.Equal(t, byte(0), version)
+	assert.Equal(t, 20, len(program))
+
+	// v1 program with a bech32 (not bech32m) checksum
+	_, _, _, err = decodeSegwitAddress("bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx")
+	assert.Equal(t, ErrInvalidSegwitAddress, err)
+
+	// mixed case
+	_, _, _, err = decodeSegwitAddress("bc1P5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr")
+	assert.Equal(t, ErrInvalidSegwitAddress, err)
+}
+
+func TestEncodeSegwitAddress_BIP350Vector(t *testing.T) {
+	program, _ := hex.DecodeString("751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6")
+	addr, err := encodeSegwitAddress("bc", 1, program)
+	assert.Nil(t, err)
+	assert.Equal(t, "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", addr)
+}
@@ -17,11 +17,13 @@ import (
 )
 
 type transactionBuilder struct {
-	wallet *HDWallet
+	wallet   *HDWallet
+	basecoin *BaseCoin // snapshot of the wallet's coin for the whole build
 }
 
 type cnSecretsSource struct {
 	wallet          *HDWallet
+	basecoin        *BaseCoin
 	usableAddresses map[string]*usableAddress
 }
 
@@ -38,7 +40,7 @@ func (s cnSecretsSource) GetScript(addr btcutil.Address) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	addrHash, err := btcutil.NewAddressScriptHash(scriptSig, s.wallet.BaseCoin.defaultNetParams())
+	addrHash, err := btcutil.NewAddressScriptHash(scriptSig, s.basecoin.defaultNetParams())
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +48,7 @@ func (s cnSecretsSource) GetScript(addr btcutil.Address) ([]byte, error) {
 }
 
 func (s cnSecretsSource) ChainParams() *chaincfg.Params {
-	return s.wallet.BaseCoin.defaultNetParams()
+	return s.basecoin.defaultNetParams()
 }
 
 func (tb transactionBuilder) buildTxFromData(data *TransactionData) (*TransactionMetadata, error) {
@@ -68,7 +70,8 @@ func (tb transactionBuilder) buildTxFromData(data *TransactionData) (*Transactio
 	// calculate change
 	var transactionChangeMetadata *TransactionChangeMetadata
 	if data.shouldAddChangeToTransaction() {
-		changeMetaAddr, err := tb.wallet.ChangeAddressForIndex(data.ChangePath.Index)
+		// use the coin snapshot, so a concurrent UpdateCoin cannot change the purpose of the change output mid-build
+		changeMetaAddr, err := tb.wallet.addressForIndex(tb.basecoin, 1, data.ChangePath.Index)
 		if err != nil {
 			return nil, err
 		}
@@ -145,14 +148,16 @@ func (tb transactionBuilder) buildTxFromData(data *TransactionData) (*Transactio
 func (tb transactionBuilder) signInputsForTx(tx *wire.MsgTx, data *TransactionData) error {
 	prevPkScripts := make([][]byte, data.UtxoCount())
 	inputValues := make([]btcutil.Amount, data.UtxoCount())
-	secretsSource := cnSecretsSource{wallet: tb.wallet, usableAddresses: make(map[string]*usableAddress)}
+	secretsSource := cnSecretsSource{wallet: tb.wallet, basecoin: tb.basecoin, usableAddresses: make(map[string]*usableAddress)}
 
 	for i := range tx.TxIn {
 		utxo, _ := data.RequiredUTXOAtIndex(i)
 
 		var address string
-		if utxo.Path != nil {
-			signer, err := newUsableAddressWithDerivationPath(tb.wallet, utxo.Path)
+		if utxo.Path != nil && utxo.Path.BaseCoin.Purpose == bip86purpose {
+			return ErrTaprootSpendingUnsupported
+		} else if utxo.Path != nil {
+			signer, err := newUsableAddressWithDerivationPath(tb.wallet, utxo.Path.clone())
 			if err != nil {
 				return err
 			}
@@ -170,7 +175,7 @@ func (tb transactionBuilder) signInputsForTx(tx *wire.MsgTx, data *TransactionDa
 			return errors.New("no source address available to sign input")
 		}
 
-		sourceAddress, err := btcutil.DecodeAddress(address, tb.wallet.BaseCoin.defaultNetParams())
+		sourceAddress, err := btcutil.DecodeAddress(address, tb.basecoin.defaultNetParams())
 		if err != nil {
 			return err
 		}
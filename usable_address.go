@@ -22,7 +22,7 @@ type usableAddress struct {
 
 // newUsableAddressWithDerivationPath accepts a wallet and derivation path, and returns a pointer to a UsableAddress.
 func newUsableAddressWithDerivationPath(wallet *HDWallet, derivationPath *DerivationPath) (*usableAddress, error) {
-	kf := keyFactory{masterPrivateKey: wallet.masterPrivateKey}
+	kf := wallet.keyFactory()
 
 	indexKey, err := kf.indexPrivateKey(derivationPath)
 	if err != nil {
@@ -101,10 +101,24 @@ func generateAddress(path *DerivationPath, pubkey *btcec.PublicKey) (string, err
 		return buildSegwitAddress(path, pubkey)
 	} else if purpose == bip49purpose {
 		return buildBIP49Address(path, pubkey)
+	} else if purpose == bip86purpose {
+		return buildTaprootAddress(path, pubkey)
+	} else if purpose == bip44purpose {
+		return buildBIP44Address(path, pubkey)
 	}
 	return "", errors.New("Unrecognized Address Purpose")
 }
 
+func buildBIP44Address(path *DerivationPath, pubkey *btcec.PublicKey) (string, error) {
+	pubkeyBytes := pubkey.SerializeCompressed()
+	keyHash := btcutil.Hash160(pubkeyBytes)
+	addr, err := btcutil.NewAddressPubKeyHash(keyHash, path.BaseCoin.defaultNetParams())
+	if err != nil {
+		return "", err
+	}
+	return addr.EncodeAddress(), nil
+}
+
 func buildBIP49Address(path *DerivationPath, pubkey *btcec.PublicKey) (string, error) {
 	pubkeyBytes := pubkey.SerializeCompressed()
 	keyHash := btcutil.Hash160(pubkeyBytes)